@@ -0,0 +1,315 @@
+/*
+Copyright © 2021 Dmytro Kostiuk <dmytro.kostiuk@mailbox.tu-dresden.de>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/exporter"
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/spf13/cobra"
+)
+
+var (
+    ErrConvertFailed     = errors.New("Error converting workbooks")
+    ErrCreatingDirectory = errors.New("Error creating directory")
+)
+
+var convertCmd = &cobra.Command{
+    Use:   "convert [workbook|glob|dir]...",
+    Short: "Import, parse, verify and export Excel workbooks without prompt",
+    Long: `Import, parse, verify and export Excel workbooks without prompt.
+
+Arguments are workbook paths, glob patterns or directories. Without
+arguments all workbooks from the manifest hazop_dir are converted.
+Output directories and report templates default to the manifest roots.`,
+    SilenceUsage: true,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        return runConvert(cmd, args)
+    },
+}
+
+var convertRoots Roots
+
+// JSONLDContextSuffix is appended to the workbook name of the published
+// JSON-LD context written next to its JSON-LD graph, the graph references it
+// by this relative IRI.
+var JSONLDContextSuffix = ".context.jsonld"
+
+func init() {
+    rootCmd.AddCommand(convertCmd)
+
+    flags := convertCmd.Flags()
+    flags.StringVar(&convertRoots.GraphDir, "graph-dir", "", "graph output directory (default manifest graph_dir)")
+    flags.StringVar(&convertRoots.ReportDir, "report-dir", "", "report output directory (default manifest report_dir)")
+    flags.StringVar(&convertRoots.ReportExt, "report-ext", "", "report format .txt (long template), .html, .json, .xml (JUnit) or .sarif (default manifest report_ext)")
+    flags.StringVar(&convertRoots.GraphExt, "graph-ext", "", "graph format .ttl, .nt, .jsonld, .trig or .nq (default manifest graph_ext)")
+    flags.StringVar(&convertRoots.SubjectPattern, "subject-pattern", "", "graph row IRI pattern (default manifest subject_pattern)")
+    flags.StringVar(&convertRoots.DateTime, "date-time", "", "fixed report date and time for reproducible output (default manifest date_time or now)")
+    flags.StringVar(&convertRoots.ReportTemplateLong, "report-template-long", "", "long report template (default manifest report_template_long)")
+    flags.StringVar(&convertRoots.ReportTemplateShort, "report-template-short", "", "short report template (default manifest report_template_short)")
+    flags.StringVar(&convertRoots.SourceRoot, "source-root", "", "directory SARIF workbook paths are relative to (default manifest source_root or the git repository root)")
+    flags.BoolVar(&convertRoots.Annotate, "annotate", false, "write an annotated copy of every workbook into the report directory (default manifest annotate)")
+}
+
+// override returns a copy of r with all non-empty fields of o applied,
+// booleans are applied by the caller if their flag is set.
+func (r Roots) override(o Roots) Roots {
+    if o.GraphDir != "" {
+        r.GraphDir = o.GraphDir
+    }
+    if o.ReportDir != "" {
+        r.ReportDir = o.ReportDir
+    }
+    if o.ReportExt != "" {
+        r.ReportExt = o.ReportExt
+    }
+    if o.GraphExt != "" {
+        r.GraphExt = o.GraphExt
+    }
+    if o.SubjectPattern != "" {
+        r.SubjectPattern = o.SubjectPattern
+    }
+    if o.DateTime != "" {
+        r.DateTime = o.DateTime
+    }
+    if o.ReportTemplateLong != "" {
+        r.ReportTemplateLong = o.ReportTemplateLong
+    }
+    if o.ReportTemplateShort != "" {
+        r.ReportTemplateShort = o.ReportTemplateShort
+    }
+    if o.SourceRoot != "" {
+        r.SourceRoot = o.SourceRoot
+    }
+    return r
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+    r := roots.override(convertRoots)
+    if cmd.Flags().Changed("annotate") {
+        r.Annotate = convertRoots.Annotate
+    }
+
+    fpaths, err := hazopFiles(args, r)
+    if err != nil {
+        return err
+    }
+
+    var failed int
+    for _, fpath := range fpaths {
+        e, err := exportWorkbook(cmd.Context(), fpath, r)
+        if err != nil {
+            failed += 1
+            cmd.PrintErrf("🔺 `%s`: %v\n", fpath, err)
+            continue
+        }
+
+        if err := e.ExportToStdout(r.ReportTemplateShort); err != nil {
+            return err
+        }
+    }
+
+    cmd.Printf("\nConverted %d of %d workbook(s)\n", len(fpaths)-failed, len(fpaths))
+
+    if failed > 0 {
+        return fmt.Errorf("%v: %d of %d failed", ErrConvertFailed, failed, len(fpaths))
+    }
+
+    return nil
+}
+
+// sourceRoot returns the directory SARIF workbook paths are relative to, by
+// default the git repository root of the working directory or the working
+// directory itself.
+func sourceRoot(root string) string {
+    if root != "" {
+        return root
+    }
+
+    dir, err := os.Getwd()
+    if err != nil {
+        return ""
+    }
+    for d := dir; ; d = filepath.Dir(d) {
+        if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+            return d
+        }
+        if filepath.Dir(d) == d {
+            return dir
+        }
+    }
+}
+
+// hazopFiles expands workbook paths, glob patterns and directories into
+// a list of cleaned workbook paths, every path is listed once. Without
+// arguments the hazop directory of the manifest is used.
+func hazopFiles(args []string, r Roots) ([]string, error) {
+    if len(args) == 0 {
+        args = []string{r.HazopDir}
+    }
+
+    var fpaths []string
+    seen := make(map[string]bool)
+    add := func(fpath string) {
+        fpath = filepath.Clean(fpath)
+        if !seen[fpath] {
+            seen[fpath] = true
+            fpaths = append(fpaths, fpath)
+        }
+    }
+
+    for _, arg := range args {
+        matches, err := filepath.Glob(arg)
+        if err != nil {
+            return nil, fmt.Errorf("%v `%s`: %v", ErrNoHazopFiles, arg, err)
+        }
+
+        if len(matches) == 0 {
+            return nil, fmt.Errorf("%v `%s`", ErrNoHazopFiles, arg)
+        }
+
+        for _, m := range matches {
+            info, err := os.Stat(m)
+            if err != nil {
+                return nil, err
+            }
+
+            if !info.IsDir() {
+                add(m)
+                continue
+            }
+
+            files, err := ioutil.ReadDir(m)
+            if err != nil {
+                return nil, fmt.Errorf("%v `%s` %v", ErrReadingDirecotry, m, err)
+            }
+
+            for _, f := range files {
+                if !f.IsDir() && strings.HasSuffix(f.Name(), r.HazopExt) {
+                    add(filepath.Join(m, f.Name()))
+                }
+            }
+        }
+    }
+
+    if len(fpaths) == 0 {
+        return nil, fmt.Errorf("%v %v", ErrNoHazopFiles, args)
+    }
+
+    return fpaths, nil
+}
+
+// exportWorkbook imports the workbook under fpath and writes its graph and
+// long report into the directories of r. The returned exporter can be used
+// to print further reports.
+func exportWorkbook(ctx context.Context, fpath string, r Roots) (*exporter.Exporter, error) {
+    wb, err := importWorkbook(ctx, fpath)
+    if err != nil {
+        return nil, err
+    }
+
+    return exportImported(wb, r)
+}
+
+// importWorkbook imports the workbook under fpath with the options set by
+// persistent flags.
+func importWorkbook(ctx context.Context, fpath string) (*importer.Workbook, error) {
+    wb, err := importer.ImportWorkbookContext(ctx, fpath, importOptions)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(wb.Worksheets) == 0 {
+        return nil, ErrNoWorksheetsFound
+    }
+
+    return wb, nil
+}
+
+// exportImported writes the graph, the long report and if enabled the
+// annotated copy of an imported workbook into the directories of r.
+func exportImported(wb *importer.Workbook, r Roots) (*exporter.Exporter, error) {
+
+    for _, dir := range []string{r.ReportDir, r.GraphDir} {
+        if err := os.MkdirAll(dir, 0755); err != nil {
+            return nil, fmt.Errorf("%v `%s`: %v", ErrCreatingDirectory, dir, err)
+        }
+    }
+
+    _, wbname := filepath.Split(wb.File.Path)
+    fname := strings.TrimSuffix(wbname, filepath.Ext(wbname))
+    rpath := filepath.Join(r.ReportDir, fname+r.ReportExt)
+    gpath := filepath.Join(r.GraphDir, fname+r.GraphExt)
+
+    dateTime := r.DateTime
+    if dateTime == "" {
+        dateTime = time.Now().Format(time.UnixDate)
+    }
+
+    e := &exporter.Exporter{
+        ReportPath:       rpath,
+        GraphPath:        gpath,
+        AppName:          application.Name,
+        AppVersion:       application.Version,
+        DateTime:         dateTime,
+        BaseUri:          r.BaseUri + application.Name,
+        SubjectPattern:   r.SubjectPattern,
+        SubjectReference: r.SubjectReference,
+        SourceRoot:       sourceRoot(r.SourceRoot),
+        ContextIRI:       url.PathEscape(fname + JSONLDContextSuffix),
+        Workbook:         wbname,
+        Worksheets:       wb.Worksheets,
+        File:             wb.File,
+    }
+
+    if err := e.ExportGraph(gpath); err != nil {
+        return nil, err
+    }
+
+    if r.GraphExt == ".jsonld" {
+        if err := e.ExportContext(filepath.Join(r.GraphDir, fname+JSONLDContextSuffix)); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := e.ExportReport(rpath, r.ReportTemplateLong); err != nil {
+        return nil, err
+    }
+
+    if r.Annotate {
+        apath := filepath.Join(r.ReportDir, fname+exporter.AnnotatedSuffix+filepath.Ext(wbname))
+        if err := e.ExportAnnotated(apath); err != nil {
+            return nil, err
+        }
+    }
+
+    return e, nil
+}
@@ -22,23 +22,19 @@ THE SOFTWARE.
 package cmd
 
 import (
+    "context"
     "errors"
     "fmt"
     "io/ioutil"
-    "log"
     "path/filepath"
     "strings"
-    "time"
 
-    "github.com/dimakdev/HAZOP2RDF2/pkg/exporter"
     "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
     "github.com/manifoldco/promptui"
     "github.com/spf13/cobra"
-    "github.com/spf13/viper"
 )
 
 var (
-    ErrReadingConfig     = errors.New("Error reading manifest file")
     ErrNoWorksheetsFound = errors.New("Error no worksheets found")
     ErrNoHazopFiles      = errors.New("Error no Hazop files found")
     ErrReadingDirecotry  = errors.New("Error reading directory")
@@ -51,7 +47,7 @@ var promptCmd = &cobra.Command{
     Short: "Import, parse and verify Excel workbooks",
     Long:  "Import, parse and verify Excel workbooks",
     Run: func(cmd *cobra.Command, args []string) {
-        if err := run(); err != nil {
+        if err := run(cmd.Context()); err != nil {
             cmd.PrintErrln(err)
         }
     },
@@ -59,46 +55,6 @@ var promptCmd = &cobra.Command{
 
 func init() {
     rootCmd.AddCommand(promptCmd)
-
-    viper.SetConfigName("manifest")
-    viper.SetConfigType("toml")
-    viper.AddConfigPath(".")
-
-    if err := viper.ReadInConfig(); err != nil {
-        log.Fatalf("%v: %v", ErrReadingConfig, err)
-    }
-
-    if err := viper.UnmarshalKey("hazop", &importer.Hazop); err != nil {
-        log.Fatalf("%v: %v", ErrReadingConfig, err)
-    }
-
-    if err := viper.UnmarshalKey("application", &application); err != nil {
-        log.Fatalf("%v: %v", ErrReadingConfig, err)
-    }
-
-    if err := viper.UnmarshalKey("roots", &roots); err != nil {
-        log.Fatalf("%v: %v", ErrReadingConfig, err)
-    }
-}
-
-type Application struct {
-    Author      string `mapstructure:"author"`
-    Name        string `mapstructure:"name"`
-    Description string `mapstructure:"description"`
-    Version     string `mapstructure:"version"`
-}
-
-type Roots struct {
-    HazopDir            string `mapstructure:"hazop_dir"`
-    HazopExt            string `mapstructure:"hazop_ext"`
-    ReportDir           string `mapstructure:"report_dir"`
-    ReportExt           string `mapstructure:"report_ext"`
-    GraphDir            string `mapstructure:"graph_dir"`
-    GraphExt            string `mapstructure:"graph_ext"`
-    BaseUri             string `mapstructure:"base_uri"`
-    GraphTemplate       string `mapstructure:"graph_template"`
-    ReportTemplateLong  string `mapstructure:"report_template_long"`
-    ReportTemplateShort string `mapstructure:"report_template_short"`
 }
 
 type Command struct {
@@ -107,10 +63,7 @@ type Command struct {
     Description string
 }
 
-var roots Roots
-var application Application
-
-func run() error {
+func run(ctx context.Context) error {
     hazopFiles, err := ioutil.ReadDir(roots.HazopDir)
     if err != nil {
         return fmt.Errorf("%v `%s` %v", ErrReadingDirecotry, roots.HazopDir, err)
@@ -165,36 +118,25 @@ func run() error {
         return fmt.Errorf("%v %v", ErrPromptFailed, err)
     }
 
-    wb, err := importer.ImportWorkbook(commands[i].Datapath)
+    fpath := commands[i].Datapath
+    wb, err := importWorkbook(ctx, fpath)
     if err != nil {
         return err
     }
 
-    if len(wb.Worksheets) == 0 {
-        return ErrNoWorksheetsFound
-    }
-
-    _, wbname := filepath.Split(wb.File.Path)
-    fname := strings.TrimSuffix(wbname, filepath.Ext(wbname))
-    rpath := filepath.Join(roots.ReportDir, fname+roots.ReportExt)
-    gpath := filepath.Join(roots.GraphDir, fname+roots.GraphExt)
-
-    e := &exporter.Exporter{
-        ReportPath: rpath,
-        GraphPath:  gpath,
-        AppName:    application.Name,
-        AppVersion: application.Version,
-        DateTime:   time.Now().Format(time.UnixDate),
-        BaseUri:    roots.BaseUri + application.Name,
-        Workbook:   wbname,
-        Worksheets: wb.Worksheets,
+    mapped, err := promptMapping(wb, fpath)
+    if err != nil {
+        return err
     }
 
-    if err := e.ExportToFile(gpath, roots.GraphTemplate); err != nil {
-        return err
+    if mapped {
+        if wb, err = importWorkbook(ctx, fpath); err != nil {
+            return err
+        }
     }
 
-    if err := e.ExportToFile(rpath, roots.ReportTemplateLong); err != nil {
+    e, err := exportImported(wb, roots)
+    if err != nil {
         return err
     }
 
@@ -204,3 +146,99 @@ func run() error {
 
     return nil
 }
+
+// HeaderChoice is a header cell offered for an unmatched hazop element.
+type HeaderChoice struct {
+    Cell  string
+    Label string
+}
+
+// promptMapping lets the user assign the unclaimed header cells of every
+// worksheet to its unmatched hazop elements. Assignments are added to the
+// mapping sidecar file of the workbook, which later imports read.
+func promptMapping(wb *importer.Workbook, fpath string) (bool, error) {
+    unmatched := 0
+    for _, ws := range wb.Worksheets {
+        if ws.IsValid && len(ws.UnmatchedCells()) > 0 {
+            unmatched += len(ws.UnmatchedIds())
+        }
+    }
+
+    if unmatched == 0 {
+        return false, nil
+    }
+
+    confirm := promptui.Prompt{
+        Label:     fmt.Sprintf("Assign %d unmatched header(s) by hand", unmatched),
+        IsConfirm: true,
+    }
+    if _, err := confirm.Run(); err != nil {
+        if errors.Is(err, promptui.ErrAbort) {
+            return false, nil
+        }
+        return false, fmt.Errorf("%v %v", ErrPromptFailed, err)
+    }
+
+    mpath := importer.MappingPath(fpath)
+    m, err := importer.ReadMapping(mpath)
+    if err != nil {
+        return false, err
+    }
+
+    templates := &promptui.SelectTemplates{
+        Label:    "========== {{ . }} ==========",
+        Active:   "⍈ {{ .Cell }} {{ .Label }}",
+        Inactive: "  {{ .Cell }} {{ .Label }}",
+        Selected: "⍈ {{ .Cell }} {{ .Label }}",
+    }
+
+    mapped := false
+    for _, ws := range wb.Worksheets {
+        if !ws.IsValid {
+            continue
+        }
+
+        cells := ws.UnmatchedCells()
+        for _, k := range ws.UnmatchedIds() {
+            if len(cells) == 0 {
+                break
+            }
+
+            choices := []HeaderChoice{{Label: "skip"}}
+            for _, cell := range cells {
+                choices = append(choices, HeaderChoice{Cell: cell, Label: ws.HeaderCells[cell]})
+            }
+
+            e := ws.Elements[k]
+            prompt := promptui.Select{
+                Label:     fmt.Sprintf("%s `%d:%s`", ws.Name, e.Id, e.Name),
+                Items:     choices,
+                Templates: templates,
+                Size:      8,
+            }
+
+            i, _, err := prompt.Run()
+            if err != nil {
+                return false, fmt.Errorf("%v %v", ErrPromptFailed, err)
+            }
+
+            if i == 0 {
+                continue
+            }
+
+            m.Set(ws.Name, e.Name, choices[i].Cell)
+            cells = append(cells[:i-1], cells[i:]...)
+            mapped = true
+        }
+    }
+
+    if !mapped {
+        return false, nil
+    }
+
+    if err := m.WriteFile(mpath); err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
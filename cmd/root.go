@@ -22,9 +22,19 @@ THE SOFTWARE.
 package cmd
 
 import (
+    "context"
+    "errors"
+    "log"
     "os"
+    "os/signal"
 
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
     "github.com/spf13/cobra"
+    "github.com/spf13/viper"
+)
+
+var (
+    ErrReadingConfig = errors.New("Error reading manifest file")
 )
 
 var rootCmd = &cobra.Command{
@@ -33,13 +43,68 @@ var rootCmd = &cobra.Command{
     Long:  "Hazop parser and modeling tool",
 }
 
+// Execute runs the root command, an interrupt cancels the running import.
 func Execute() {
-    err := rootCmd.Execute()
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+
+    err := rootCmd.ExecuteContext(ctx)
     if err != nil {
+        stop()
         os.Exit(1)
     }
 }
 
+// importOptions are the importer options set by persistent flags.
+var importOptions importer.Options
+
 func init() {
-    // rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+    rootCmd.PersistentFlags().IntVar(&importOptions.Workers, "workers", 0, "number of worksheets read concurrently (default number of CPUs)")
+
+    viper.SetConfigName("manifest")
+    viper.SetConfigType("toml")
+    viper.AddConfigPath(".")
+
+    if err := viper.ReadInConfig(); err != nil {
+        log.Fatalf("%v: %v", ErrReadingConfig, err)
+    }
+
+    if err := viper.UnmarshalKey("hazop", &importer.Hazop); err != nil {
+        log.Fatalf("%v: %v", ErrReadingConfig, err)
+    }
+
+    if err := viper.UnmarshalKey("application", &application); err != nil {
+        log.Fatalf("%v: %v", ErrReadingConfig, err)
+    }
+
+    if err := viper.UnmarshalKey("roots", &roots); err != nil {
+        log.Fatalf("%v: %v", ErrReadingConfig, err)
+    }
 }
+
+type Application struct {
+    Author      string `mapstructure:"author"`
+    Name        string `mapstructure:"name"`
+    Description string `mapstructure:"description"`
+    Version     string `mapstructure:"version"`
+}
+
+type Roots struct {
+    HazopDir            string `mapstructure:"hazop_dir"`
+    HazopExt            string `mapstructure:"hazop_ext"`
+    ReportDir           string `mapstructure:"report_dir"`
+    ReportExt           string `mapstructure:"report_ext"`
+    GraphDir            string `mapstructure:"graph_dir"`
+    GraphExt            string `mapstructure:"graph_ext"`
+    BaseUri             string `mapstructure:"base_uri"`
+    SubjectPattern      string `mapstructure:"subject_pattern"`
+    SubjectReference    *int   `mapstructure:"subject_reference"`
+    DateTime            string `mapstructure:"date_time"`
+    ReportTemplateLong  string `mapstructure:"report_template_long"`
+    ReportTemplateShort string `mapstructure:"report_template_short"`
+    Annotate            bool   `mapstructure:"annotate"`
+    SourceRoot          string `mapstructure:"source_root"`
+}
+
+var roots Roots
+var application Application
@@ -0,0 +1,234 @@
+/*
+Copyright © 2021 Dmytro Kostiuk <dmytro.kostiuk@mailbox.tu-dresden.de>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/spf13/cobra"
+)
+
+var (
+    ErrValidationFailed = errors.New("Error validation failed")
+    ErrUnknownElement   = errors.New("Error unknown hazop element")
+    ErrNoHazopTable     = errors.New("Error no Hazop table found")
+    ErrTooManyErrors    = errors.New("Error too many errors")
+    ErrLowAccuracy      = errors.New("Error accuracy below threshold")
+    ErrMissingHeader    = errors.New("Error required header missing")
+)
+
+var validateCmd = &cobra.Command{
+    Use:   "validate [workbook|glob|dir]...",
+    Short: "Verify Excel workbooks against thresholds without writing a graph",
+    Long: `Verify Excel workbooks against thresholds without writing a graph.
+
+Arguments are workbook paths, glob patterns or directories. Without
+arguments all workbooks from the manifest hazop_dir are validated.
+Thresholds apply to every worksheet with a Hazop table, other worksheets
+are listed as unchecked. A JSON summary is printed to stdout and the
+command exits non-zero if any threshold is violated.`,
+    SilenceUsage: true,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        return runValidate(cmd, args)
+    },
+}
+
+type Thresholds struct {
+    MaxErrors       int
+    MinPValidCells  float64
+    RequiredHeaders []string
+}
+
+var thresholds Thresholds
+
+func init() {
+    rootCmd.AddCommand(validateCmd)
+
+    flags := validateCmd.Flags()
+    flags.IntVar(&thresholds.MaxErrors, "max-errors", -1, "maximum number of errors per workbook, -1 disables the check")
+    flags.Float64Var(&thresholds.MinPValidCells, "min-valid", 0, "minimum percentage of valid cells per worksheet")
+    flags.StringSliceVar(&thresholds.RequiredHeaders, "require", nil, "hazop element names whose headers must be found, e.g. Cause,Consequence")
+}
+
+type ValidationSummary struct {
+    Passed    bool                  `json:"passed"`
+    Workbooks []*WorkbookValidation `json:"workbooks"`
+}
+
+type WorkbookValidation struct {
+    Path       string                 `json:"path"`
+    Passed     bool                   `json:"passed"`
+    Errors     int                    `json:"errors"`
+    Warnings   int                    `json:"warnings"`
+    Failures   []string               `json:"failures"`
+    Worksheets []*WorksheetValidation `json:"worksheets"`
+}
+
+type WorksheetValidation struct {
+    Index          int      `json:"index"`
+    Name           string   `json:"name"`
+    Checked        bool     `json:"checked"`
+    Passed         bool     `json:"passed"`
+    PValidCells    float64  `json:"p_valid_cells"`
+    NValidCells    int      `json:"n_valid_cells"`
+    NCells         int      `json:"n_cells"`
+    Errors         int      `json:"errors"`
+    Warnings       int      `json:"warnings"`
+    Headers        []string `json:"headers"`
+    MissingHeaders []string `json:"missing_headers"`
+    Failures       []string `json:"failures"`
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+    names := make(map[string]bool, len(importer.Hazop.Elements))
+    for _, e := range importer.Hazop.Elements {
+        names[e.Name] = true
+    }
+
+    for _, name := range thresholds.RequiredHeaders {
+        if !names[name] {
+            return fmt.Errorf("%v `%s`", ErrUnknownElement, name)
+        }
+    }
+
+    fpaths, err := hazopFiles(args, roots)
+    if err != nil {
+        return err
+    }
+
+    summary := &ValidationSummary{Passed: true}
+    for _, fpath := range fpaths {
+        if err := cmd.Context().Err(); err != nil {
+            return err
+        }
+
+        v := validateWorkbook(cmd.Context(), fpath, thresholds)
+        summary.Passed = summary.Passed && v.Passed
+        summary.Workbooks = append(summary.Workbooks, v)
+    }
+
+    out, err := json.MarshalIndent(summary, "", "  ")
+    if err != nil {
+        return err
+    }
+    cmd.Println(string(out))
+
+    if !summary.Passed {
+        return ErrValidationFailed
+    }
+
+    return nil
+}
+
+func validateWorkbook(ctx context.Context, fpath string, t Thresholds) *WorkbookValidation {
+    v := &WorkbookValidation{
+        Path:       fpath,
+        Failures:   []string{},
+        Worksheets: []*WorksheetValidation{},
+    }
+
+    wb, err := importer.ImportWorkbookContext(ctx, fpath, importOptions)
+    if err != nil {
+        v.Failures = append(v.Failures, err.Error())
+        return v
+    }
+
+    var nchecked int
+    for _, ws := range wb.Worksheets {
+        wsv := validateWorksheet(ws, ws.Elements, t)
+        if wsv.Checked {
+            nchecked += 1
+            v.Errors += wsv.Errors
+            v.Warnings += wsv.Warnings
+            for _, f := range wsv.Failures {
+                v.Failures = append(v.Failures, fmt.Sprintf("%s: %s", wsv.Name, f))
+            }
+        }
+        v.Worksheets = append(v.Worksheets, wsv)
+    }
+
+    if nchecked == 0 {
+        v.Failures = append(v.Failures, ErrNoHazopTable.Error())
+    }
+
+    if t.MaxErrors >= 0 && v.Errors > t.MaxErrors {
+        v.Failures = append(v.Failures, fmt.Sprintf("%v %d > %d",
+            ErrTooManyErrors,
+            v.Errors,
+            t.MaxErrors,
+        ))
+    }
+
+    v.Passed = len(v.Failures) == 0
+
+    return v
+}
+
+func validateWorksheet(ws *importer.Worksheet, elements map[int]importer.HazopElement, t Thresholds) *WorksheetValidation {
+    v := &WorksheetValidation{
+        Index:          ws.Index,
+        Name:           ws.Name,
+        Checked:        ws.IsValid,
+        PValidCells:    ws.PValidCells,
+        NValidCells:    ws.NValidCells,
+        NCells:         ws.NCells,
+        Errors:         ws.Report.Count(importer.SeverityError),
+        Warnings:       ws.Report.Count(importer.SeverityWarning),
+        Headers:        []string{},
+        MissingHeaders: []string{},
+        Failures:       []string{},
+    }
+
+    found := make(map[string]bool, len(ws.Headers))
+    for _, k := range ws.HeaderIds() {
+        found[elements[k].Name] = true
+        v.Headers = append(v.Headers, elements[k].Name)
+    }
+
+    if !v.Checked {
+        v.Passed = true
+        return v
+    }
+
+    for _, name := range t.RequiredHeaders {
+        if !found[name] {
+            v.MissingHeaders = append(v.MissingHeaders, name)
+            v.Failures = append(v.Failures, fmt.Sprintf("%v `%s`", ErrMissingHeader, name))
+        }
+    }
+
+    if ws.PValidCells < t.MinPValidCells {
+        v.Failures = append(v.Failures, fmt.Sprintf("%v %.2f%% < %.2f%%",
+            ErrLowAccuracy,
+            ws.PValidCells,
+            t.MinPValidCells,
+        ))
+    }
+
+    v.Passed = len(v.Failures) == 0
+
+    return v
+}
@@ -0,0 +1,319 @@
+package exporter
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/xuri/excelize/v2"
+)
+
+// AnnotatedSuffix is appended to the workbook name of annotated copies.
+const AnnotatedSuffix = ".annotated"
+
+// SummarySheet is the name of the summary sheet of annotated copies.
+var SummarySheet = "Validation"
+
+// MaxSummaryLinks is the number of hyperlinks Excel allows in a worksheet.
+const MaxSummaryLinks = excelize.TotalSheetHyperlinks + 1
+
+var (
+    ErrNoSourceWorkbook = errors.New("Error no source workbook")
+    ErrAnnotating       = errors.New("Error annotating workbook")
+)
+
+// severityFills are the fill colours of cells by the highest severity of
+// their diagnostics.
+var severityFills = map[importer.Severity]string{
+    importer.SeverityError:   "#FFC7CE",
+    importer.SeverityWarning: "#FFEB9C",
+}
+
+// annotation collects the error and warning diagnostics of a cell.
+type annotation struct {
+    severity importer.Severity
+    messages []string
+}
+
+// styleKey identifies the annotated style of a base style and severity.
+type styleKey struct {
+    base     int
+    severity importer.Severity
+}
+
+// annotator marks the cells of a copy of the source workbook.
+type annotator struct {
+    f      *excelize.File
+    author string
+    styles map[styleKey]int
+}
+
+// ExportAnnotated writes a copy of the source workbook to fpath. Every cell
+// with an error or warning is filled by severity and gets a comment with the
+// messages, a summary sheet lists the accuracy and diagnostics per worksheet.
+// The source workbook is not changed.
+func (e *Exporter) ExportAnnotated(fpath string) error {
+    if e.File == nil {
+        return ErrNoSourceWorkbook
+    }
+
+    buf, err := e.File.WriteToBuffer()
+    if err != nil {
+        return fmt.Errorf("%w `%s`: %v", ErrAnnotating, fpath, err)
+    }
+
+    f, err := excelize.OpenReader(buf)
+    if err != nil {
+        return fmt.Errorf("%w `%s`: %v", ErrAnnotating, fpath, err)
+    }
+
+    a := &annotator{
+        f:      f,
+        author: e.AppName + ": ",
+        styles: make(map[styleKey]int),
+    }
+
+    for _, ws := range e.Worksheets {
+        if f.GetSheetIndex(ws.Name) < 0 {
+            continue
+        }
+        if err := a.annotateSheet(ws); err != nil {
+            return fmt.Errorf("%w `%s`: %v", ErrAnnotating, fpath, err)
+        }
+    }
+
+    if err := e.writeSummary(f); err != nil {
+        return fmt.Errorf("%w `%s`: %v", ErrAnnotating, fpath, err)
+    }
+
+    w, err := os.Create(fpath)
+    if err != nil {
+        return fmt.Errorf("%v `%s`: %v", ErrCreatingOutputFile, fpath, err)
+    }
+    defer w.Close()
+
+    if err := f.Write(w); err != nil {
+        return fmt.Errorf("%v `%s`: %v", ErrCreatingOutputFile, fpath, err)
+    }
+
+    return w.Close()
+}
+
+// cellAnnotations returns the error and warning diagnostics of the worksheet
+// by cell in report order.
+func cellAnnotations(ws *importer.Worksheet) ([]string, map[string]*annotation) {
+    var cells []string
+    annotations := make(map[string]*annotation)
+    for _, d := range ws.Report.Diagnostics {
+        if d.Cell == "" || (d.Severity != importer.SeverityError && d.Severity != importer.SeverityWarning) {
+            continue
+        }
+
+        a, ok := annotations[d.Cell]
+        if !ok {
+            a = &annotation{severity: d.Severity}
+            annotations[d.Cell] = a
+            cells = append(cells, d.Cell)
+        }
+        if d.Severity == importer.SeverityError {
+            a.severity = d.Severity
+        }
+        a.messages = append(a.messages, d.Message)
+    }
+    return cells, annotations
+}
+
+// annotateSheet fills and comments the cells of the worksheet with
+// diagnostics. Cells with a comment of their own keep it.
+func (a *annotator) annotateSheet(ws *importer.Worksheet) error {
+    commented := make(map[string]bool)
+    for _, c := range a.f.GetComments()[ws.Name] {
+        commented[c.Ref] = true
+    }
+
+    cells, annotations := cellAnnotations(ws)
+    for _, cell := range cells {
+        an := annotations[cell]
+
+        style, err := a.cellStyle(ws.Name, cell, an.severity)
+        if err != nil {
+            return err
+        }
+        if err := a.f.SetCellStyle(ws.Name, cell, cell, style); err != nil {
+            return err
+        }
+
+        if commented[cell] {
+            continue
+        }
+
+        format, err := json.Marshal(map[string]string{
+            "author": a.author,
+            "text":   strings.Join(an.messages, "\n"),
+        })
+        if err != nil {
+            return err
+        }
+        if err := a.f.AddComment(ws.Name, cell, string(format)); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// cellStyle returns the style of the cell with the fill of the severity, one
+// style is created per base style and severity. The number format of the
+// cell is kept.
+func (a *annotator) cellStyle(sheet, cell string, s importer.Severity) (int, error) {
+    base, err := a.f.GetCellStyle(sheet, cell)
+    if err != nil {
+        return 0, err
+    }
+
+    key := styleKey{base: base, severity: s}
+    if style, ok := a.styles[key]; ok {
+        return style, nil
+    }
+
+    style := a.numFmt(base)
+    style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{severityFills[s]}}
+    id, err := a.f.NewStyle(style)
+    if err != nil {
+        return 0, err
+    }
+
+    a.styles[key] = id
+    return id, nil
+}
+
+// numFmt returns a style with the number format of the style id, built-in
+// formats by id and custom formats by their code. The styles of the workbook
+// are only read.
+func (a *annotator) numFmt(id int) *excelize.Style {
+    style := &excelize.Style{}
+    ss := a.f.Styles
+    if ss == nil || ss.CellXfs == nil || id <= 0 || id >= len(ss.CellXfs.Xf) {
+        return style
+    }
+
+    xf := ss.CellXfs.Xf[id]
+    if xf.NumFmtID == nil {
+        return style
+    }
+
+    style.NumFmt = *xf.NumFmtID
+    if ss.NumFmts != nil {
+        for _, nf := range ss.NumFmts.NumFmt {
+            if nf.NumFmtID == *xf.NumFmtID {
+                code := nf.FormatCode
+                style.CustomNumFmt = &code
+                break
+            }
+        }
+    }
+    return style
+}
+
+// summaryName returns a sheet name for the summary not taken by the workbook.
+func summaryName(f *excelize.File) string {
+    name := SummarySheet
+    for i := 2; f.GetSheetIndex(name) >= 0; i++ {
+        name = fmt.Sprintf("%s %d", SummarySheet, i)
+    }
+    return name
+}
+
+// writeSummary adds the summary sheet with the accuracy and report counts of
+// every worksheet followed by its errors and warnings, and makes it active.
+// Cells of the first MaxSummaryLinks findings link to the marked cell, the
+// other cell addresses are plain text.
+func (e *Exporter) writeSummary(f *excelize.File) error {
+    name := summaryName(f)
+    idx := f.NewSheet(name)
+
+    bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+    if err != nil {
+        return err
+    }
+
+    rows := [][]interface{}{
+        {"Program", e.AppName + " " + e.AppVersion},
+        {"Date and time", e.DateTime},
+        {"Workbook", e.Workbook},
+        {},
+        {"Worksheet", "Accuracy (%)", "Valid cells", "Cells", "Errors", "Warnings", "Info"},
+    }
+    for _, ws := range e.Worksheets {
+        rows = append(rows, []interface{}{
+            ws.Name,
+            ws.PValidCells,
+            ws.NValidCells,
+            ws.NCells,
+            ws.Report.Count(importer.SeverityError),
+            ws.Report.Count(importer.SeverityWarning),
+            ws.Report.Count(importer.SeverityInfo),
+        })
+    }
+    diagnosticsRow := len(rows) + 2
+    rows = append(rows, []interface{}{}, []interface{}{
+        "Worksheet", "Cell", "Severity", "Code", "Element", "Value", "Expected", "Message",
+    })
+
+    var links [][2]string
+    for _, ws := range e.Worksheets {
+        for _, d := range ws.Report.Diagnostics {
+            if d.Severity != importer.SeverityError && d.Severity != importer.SeverityWarning {
+                continue
+            }
+
+            var element string
+            if d.Element != nil {
+                element = ws.Elements[*d.Element].Name
+            }
+
+            rows = append(rows, []interface{}{
+                ws.Name, d.Cell, string(d.Severity), d.Code, element, d.Value, d.Expected, d.Message,
+            })
+            if d.Cell != "" && len(links) < MaxSummaryLinks {
+                axis, _ := excelize.CoordinatesToCellName(2, len(rows))
+                links = append(links, [2]string{axis, quoteSheet(ws.Name) + "!" + d.Cell})
+            }
+        }
+    }
+
+    for i, row := range rows {
+        axis, _ := excelize.CoordinatesToCellName(1, i+1)
+        if err := f.SetSheetRow(name, axis, &row); err != nil {
+            return err
+        }
+    }
+
+    for _, link := range links {
+        if err := f.SetCellHyperLink(name, link[0], link[1], "Location"); err != nil {
+            return err
+        }
+    }
+
+    for _, row := range []int{5, diagnosticsRow} {
+        hcell, _ := excelize.CoordinatesToCellName(1, row)
+        vcell, _ := excelize.CoordinatesToCellName(8, row)
+        if err := f.SetCellStyle(name, hcell, vcell, bold); err != nil {
+            return err
+        }
+    }
+
+    if err := f.SetColWidth(name, "A", "A", 24); err != nil {
+        return err
+    }
+    if err := f.SetColWidth(name, "H", "H", 80); err != nil {
+        return err
+    }
+
+    f.SetActiveSheet(idx)
+
+    return nil
+}
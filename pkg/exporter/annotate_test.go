@@ -0,0 +1,153 @@
+package exporter
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestExportAnnotated(t *testing.T) {
+    assert := assert.New(t)
+
+    src := excelize.NewFile()
+    src.SetSheetRow("Sheet1", "A1", &[]interface{}{"Reference", "Deviation"})
+    src.SetSheetRow("Sheet1", "A2", &[]interface{}{"one", "More flow"})
+    src.SetSheetRow("Sheet1", "A3", &[]interface{}{44259, "Less flow"})
+    date, _ := src.NewStyle(&excelize.Style{NumFmt: 14})
+    src.SetCellStyle("Sheet1", "A3", "A3", date)
+
+    id := 2
+    ws := &importer.Worksheet{
+        Name:        "Sheet1",
+        PValidCells: 50,
+        NValidCells: 2,
+        NCells:      4,
+        Elements:    map[int]importer.HazopElement{2: {Id: 2, Name: "Reference"}},
+        Report: &importer.Report{Diagnostics: []importer.Diagnostic{
+            {Code: importer.CodeHeaderFound, Severity: importer.SeverityInfo, Cell: "A1", Message: "found"},
+            {Code: importer.CodeHeaderNotFound, Severity: importer.SeverityError, Element: &id, Message: "not found"},
+            {Code: importer.CodeParsingInteger, Severity: importer.SeverityError, Cell: "A2", Element: &id, Value: "one", Message: "integer `A2`"},
+            {Code: importer.CodeValueOutOfRange, Severity: importer.SeverityWarning, Cell: "A3", Message: "range `A3`"},
+        }},
+    }
+
+    exp := &Exporter{AppName: "HAZOP2RDF2", Workbook: "Hazop.xlsx", Worksheets: []*importer.Worksheet{ws}}
+    fpath := filepath.Join(t.TempDir(), "Hazop.annotated.xlsx")
+    assert.ErrorIs(exp.ExportAnnotated(fpath), ErrNoSourceWorkbook)
+
+    exp.File = src
+    assert.Empty(exp.ExportAnnotated(fpath))
+    assert.Equal([]string{"Sheet1"}, src.GetSheetList())
+
+    f, err := excelize.OpenFile(fpath)
+    assert.Empty(err)
+    assert.Equal([]string{"Sheet1", SummarySheet}, f.GetSheetList())
+
+    comments := f.GetComments()["Sheet1"]
+    assert.Len(comments, 2)
+    assert.Equal("A2", comments[0].Ref)
+    assert.Equal("HAZOP2RDF2: integer `A2`", comments[0].Text)
+
+    style, _ := f.GetCellStyle("Sheet1", "A1")
+    assert.Zero(style)
+    style, _ = f.GetCellStyle("Sheet1", "A3")
+    xf := f.Styles.CellXfs.Xf[style]
+    assert.Equal(14, *xf.NumFmtID)
+    assert.Equal("FFEB9C", f.Styles.Fills.Fill[*xf.FillID].PatternFill.FgColor.RGB[2:])
+    value, _ := f.GetCellValue("Sheet1", "A3")
+    assert.Equal("03-04-21", value)
+
+    rows, err := f.GetRows(SummarySheet)
+    assert.Empty(err)
+    assert.Equal([]string{"Sheet1", "50", "2", "4", "2", "1", "1"}, rows[5])
+    assert.Equal([]string{"Sheet1", "", "error", "header-not-found", "Reference", "", "", "not found"}, rows[8])
+    assert.Equal([]string{"Sheet1", "A2", "error", "parsing-integer", "Reference", "one", "", "integer `A2`"}, rows[9])
+    assert.Len(rows, 11)
+
+    _, link, _ := f.GetCellHyperLink(SummarySheet, "B10")
+    assert.Equal("'Sheet1'!A2", link)
+}
+
+func TestExportAnnotatedImported(t *testing.T) {
+    assert := assert.New(t)
+
+    maxValue := 100.0
+    hazop := &importer.HazopElements{Elements: []importer.HazopElement{
+        {Id: 2, Name: "Reference", Regex: "^(?i)(reference)", DataType: "integer", MaxLen: 10, MaxValue: &maxValue},
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", DataType: "string", MaxLen: 80},
+    }}
+
+    dir := t.TempDir()
+    src := excelize.NewFile()
+    src.SetSheetRow("Sheet1", "A1", &[]interface{}{"Reference", "Deviation"})
+    src.SetSheetRow("Sheet1", "A2", &[]interface{}{1, "More flow"})
+    src.SetSheetRow("Sheet1", "A3", &[]interface{}{"two", "Less flow"})
+    assert.Empty(src.SaveAs(filepath.Join(dir, "Hazop.xlsx")))
+
+    wb, err := importer.ImportWorkbookContext(context.Background(), filepath.Join(dir, "Hazop.xlsx"), importer.Options{Hazop: hazop})
+    assert.Empty(err)
+    assert.Empty(wb.File.Close())
+
+    exp := &Exporter{AppName: "HAZOP2RDF2", Workbook: "Hazop.xlsx", Worksheets: wb.Worksheets, File: wb.File}
+    fpath := filepath.Join(dir, "Hazop.annotated.xlsx")
+    assert.Empty(exp.ExportAnnotated(fpath))
+
+    f, err := excelize.OpenFile(fpath)
+    assert.Empty(err)
+    defer f.Close()
+    assert.Equal([]string{"Sheet1", SummarySheet}, f.GetSheetList())
+
+    comments := f.GetComments()["Sheet1"]
+    assert.Len(comments, 1)
+    assert.Equal("A3", comments[0].Ref)
+    value, _ := f.GetCellValue("Sheet1", "A3")
+    assert.Equal("two", value)
+
+    ref, err := os.Create(filepath.Join(dir, "report.txt"))
+    assert.Empty(err)
+    assert.Empty(ref.Close())
+    want, _ := os.Stat(ref.Name())
+    info, err := os.Stat(fpath)
+    assert.Empty(err)
+    assert.Equal(want.Mode().Perm(), info.Mode().Perm())
+}
+
+func TestExportAnnotatedLinkLimit(t *testing.T) {
+    assert := assert.New(t)
+
+    // the worksheet is not in the workbook, only the summary is written
+    src := excelize.NewFile()
+    ws := &importer.Worksheet{Name: "Node", Report: &importer.Report{}}
+    n := MaxSummaryLinks + 5
+    for i := 1; i <= n; i++ {
+        ws.Report.Add(importer.Diagnostic{
+            Code:     importer.CodeValueOutOfRange,
+            Severity: importer.SeverityWarning,
+            Cell:     fmt.Sprintf("A%d", i),
+            Message:  "range",
+        })
+    }
+
+    exp := &Exporter{AppName: "HAZOP2RDF2", Workbook: "Hazop.xlsx", Worksheets: []*importer.Worksheet{ws}, File: src}
+    fpath := filepath.Join(t.TempDir(), "Hazop.annotated.xlsx")
+    assert.Empty(exp.ExportAnnotated(fpath))
+
+    f, err := excelize.OpenFile(fpath)
+    assert.Empty(err)
+    defer f.Close()
+
+    last := fmt.Sprintf("B%d", 8+n)
+    ok, link, _ := f.GetCellHyperLink(SummarySheet, "B9")
+    assert.True(ok)
+    assert.Equal("'Node'!A1", link)
+    ok, _, _ = f.GetCellHyperLink(SummarySheet, last)
+    assert.False(ok)
+    value, _ := f.GetCellValue(SummarySheet, last)
+    assert.Equal(fmt.Sprintf("A%d", n), value)
+}
@@ -3,27 +3,52 @@ package exporter
 import (
     "errors"
     "fmt"
+    "net/url"
     "os"
+    "path/filepath"
+    "strconv"
+    "strings"
     "text/template"
 
     "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/xuri/excelize/v2"
 )
 
 type Exporter struct {
-    ReportPath string
-    GraphPath  string
-    AppName    string
-    AppVersion string
-    DateTime   string
-    BaseUri    string
-    Workbook   string
-    Worksheets map[int]*importer.Worksheet
+    ReportPath       string
+    GraphPath        string
+    AppName          string
+    AppVersion       string
+    DateTime         string
+    BaseUri          string
+    SubjectPattern   string
+    SubjectReference *int
+    SourceRoot       string
+    ContextIRI       string
+    Workbook         string
+    Worksheets       []*importer.Worksheet
+    File             *excelize.File
 }
 
+// quoteSheet returns the worksheet name quoted for cell references, e.g.
+// `'Node 1'` as in `'Node 1'!F2`.
+func quoteSheet(name string) string {
+    return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// DefaultSubjectPattern identifies every graph row by its workbook,
+// worksheet and row number.
+var DefaultSubjectPattern = "{workbook}/{worksheet}/{row}"
+
+// DefaultSubjectReference is the id of the element whose value replaces the
+// {reference} placeholder, the Reference element of the manifest.
+var DefaultSubjectReference = 2
+
 var (
     ErrCreatingOutputFile  = errors.New("Error creating output file")
     ErrReadingTemplateFile = errors.New("Error reading template file")
     ErrWritingTemplateFile = errors.New("Error writing template file")
+    ErrSubjectReference    = errors.New("Error subject reference")
 )
 
 func (e *Exporter) ExportToFile(fpath, tpath string) error {
@@ -57,3 +82,60 @@ func (e *Exporter) ExportToStdout(tpath string) error {
 
     return nil
 }
+
+// Subject returns the IRI of the graph row i of the worksheet ws. The
+// placeholders {workbook}, {worksheet}, {row}, {index} and {reference} of
+// the subject pattern are replaced by their path escaped values. The
+// reference is the value of the element SubjectReference, a row without it
+// is an error.
+func (e *Exporter) Subject(ws *importer.Worksheet, i int) (string, error) {
+    pattern := e.SubjectPattern
+    if pattern == "" {
+        pattern = DefaultSubjectPattern
+    }
+
+    var reference string
+    if strings.Contains(pattern, "{reference}") {
+        v, err := e.subjectReference(ws, i)
+        if err != nil {
+            return "", err
+        }
+        reference = v
+    }
+
+    workbook := strings.TrimSuffix(e.Workbook, filepath.Ext(e.Workbook))
+    r := strings.NewReplacer(
+        "{workbook}", url.PathEscape(workbook),
+        "{worksheet}", url.PathEscape(ws.Name),
+        "{row}", strconv.Itoa(ws.Row(i)),
+        "{index}", strconv.Itoa(i),
+        "{reference}", url.PathEscape(reference),
+    )
+
+    return e.BaseUri + "/hazopnode/" + r.Replace(pattern), nil
+}
+
+// subjectReference returns the value of the reference element in the graph
+// row i of the worksheet ws.
+func (e *Exporter) subjectReference(ws *importer.Worksheet, i int) (string, error) {
+    id := DefaultSubjectReference
+    if e.SubjectReference != nil {
+        id = *e.SubjectReference
+    }
+
+    el, ok := ws.Elements[id]
+    if !ok {
+        return "", fmt.Errorf("%w: no element `%d`", ErrSubjectReference, id)
+    }
+
+    var v string
+    if i < len(ws.Graph) {
+        if value, ok := ws.Graph[i][el.Name]; ok {
+            v = fmt.Sprint(value)
+        }
+    }
+    if v == "" {
+        return "", fmt.Errorf("%w: no `%d:%s` in row %d", ErrSubjectReference, el.Id, el.Name, ws.Row(i))
+    }
+    return v, nil
+}
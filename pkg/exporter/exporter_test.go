@@ -4,6 +4,7 @@ import (
     "os"
     "testing"
 
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
     "github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +46,42 @@ func TestExportToStdout(t *testing.T) {
     err = exp.ExportToStdout(tpath)
     assert.Empty(err)
 }
+
+func TestSubject(t *testing.T) {
+    assert := assert.New(t)
+
+    ws := &importer.Worksheet{
+        Name:      "Node 4.4",
+        HeaderRow: 1,
+        Graph:     []map[string]interface{}{{"Ref": 7}, {}},
+        Elements:  map[int]importer.HazopElement{2: {Id: 2, Name: "Ref"}},
+    }
+
+    exp := &Exporter{BaseUri: "http://x", Workbook: "Hazop.xlsx"}
+    s, err := exp.Subject(ws, 0)
+    assert.Empty(err)
+    assert.Equal("http://x/hazopnode/Hazop/Node%204.4/2", s)
+    s, err = exp.Subject(ws, 1)
+    assert.Empty(err)
+    assert.Equal("http://x/hazopnode/Hazop/Node%204.4/3", s)
+
+    exp.SubjectPattern = "{worksheet}-{index}-{reference}"
+    s, err = exp.Subject(ws, 0)
+    assert.Empty(err)
+    assert.Equal("http://x/hazopnode/Node%204.4-0-7", s)
+
+    _, err = exp.Subject(ws, 1)
+    assert.ErrorIs(err, ErrSubjectReference)
+    assert.EqualError(err, ErrSubjectReference.Error()+": no `2:Ref` in row 3")
+
+    id := 5
+    exp.SubjectReference = &id
+    _, err = exp.Subject(ws, 0)
+    assert.EqualError(err, ErrSubjectReference.Error()+": no element `5`")
+
+    ws.Elements[5] = importer.HazopElement{Id: 5, Name: "Tag"}
+    ws.Graph[0]["Tag"] = "P 101"
+    s, err = exp.Subject(ws, 0)
+    assert.Empty(err)
+    assert.Equal("http://x/hazopnode/Node%204.4-0-P%20101", s)
+}
@@ -0,0 +1,304 @@
+package exporter
+
+import (
+    "bufio"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "net/url"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/dimakdev/HAZOP2RDF2/pkg/rdf"
+)
+
+var (
+    ErrUnknownGraphFormat = errors.New("Error unknown graph format")
+    ErrBuildingGraph      = errors.New("Error building graph")
+)
+
+// GraphWriter serializes the dataset d, c is the JSON-LD context of the
+// hazop elements.
+type GraphWriter func(w io.Writer, d *rdf.Dataset, c *rdf.Context) error
+
+// GraphWriters maps graph file extensions to their RDF serializers.
+var GraphWriters = map[string]GraphWriter{
+    ".ttl": func(w io.Writer, d *rdf.Dataset, c *rdf.Context) error {
+        return rdf.WriteTurtle(w, d.Union())
+    },
+    ".nt": func(w io.Writer, d *rdf.Dataset, c *rdf.Context) error {
+        return rdf.WriteNTriples(w, d.Union())
+    },
+    ".jsonld": rdf.WriteJSONLD,
+    ".trig": func(w io.Writer, d *rdf.Dataset, c *rdf.Context) error {
+        return rdf.WriteTriG(w, d)
+    },
+    ".nq": func(w io.Writer, d *rdf.Dataset, c *rdf.Context) error {
+        return rdf.WriteNQuads(w, d)
+    },
+}
+
+// Namespace returns the namespace IRI of the hazop vocabulary name, e.g.
+// hazopedge for properties or hazopnode for resources.
+func (e *Exporter) Namespace(name string) rdf.IRI {
+    return rdf.IRI(e.BaseUri + "/" + name + "#")
+}
+
+// Property returns the hazopedge property IRI of the hazop element.
+func (e *Exporter) Property(el importer.HazopElement) rdf.IRI {
+    return e.Namespace("hazopedge") + rdf.IRI(strings.ToLower(el.Name))
+}
+
+// Item returns the IRI of a split cell item of the hazop element, items with
+// the same text regardless of case and spacing share their IRI.
+func (e *Exporter) Item(el importer.HazopElement, lit rdf.Literal) rdf.IRI {
+    text := strings.ToLower(strings.Join(strings.Fields(lit.Value), " "))
+    sum := sha256.Sum256([]byte(text))
+    return rdf.IRI(e.BaseUri + "/hazopnode/" + url.PathEscape(strings.ToLower(el.Name)) + "/" + hex.EncodeToString(sum[:8]))
+}
+
+// WorkbookName returns the IRI of the workbook.
+func (e *Exporter) WorkbookName() rdf.IRI {
+    workbook := strings.TrimSuffix(e.Workbook, filepath.Ext(e.Workbook))
+    return rdf.IRI(e.BaseUri + "/hazopnode/" + url.PathEscape(workbook))
+}
+
+// GraphName returns the IRI of the worksheet ws, which also names the graph
+// of its rows.
+func (e *Exporter) GraphName(ws *importer.Worksheet) rdf.IRI {
+    return e.WorkbookName() + rdf.IRI("/"+url.PathEscape(ws.Name))
+}
+
+// Dataset returns the RDF dataset of the workbook with one named graph of
+// rows per worksheet. Every row is a subject with one property per hazop
+// element, missing values point to hazoperro:empty. Items of split cells are
+// resources typed by the element name and labeled by their value. The
+// default graph describes the workbook and its worksheets.
+func (e *Exporter) Dataset() (*rdf.Dataset, error) {
+    d := rdf.NewDataset()
+    d.Bind("xsd", rdf.XSD)
+    d.Bind("rdfs", rdf.RDFS)
+    for _, ns := range []string{"hazopnode", "hazopedge", "hazoperro"} {
+        if err := d.Bind(ns, e.Namespace(ns)); err != nil {
+            return nil, err
+        }
+    }
+
+    empty := e.Namespace("hazoperro") + "empty"
+
+    node := e.Namespace("hazopnode")
+    edge := e.Namespace("hazopedge")
+    wb := e.WorkbookName()
+    d.Default.Add(wb, rdf.RDFType, node+"Workbook")
+    d.Default.Add(wb, rdf.RDFS+"label", rdf.NewLiteral(e.Workbook))
+    for _, ws := range e.Worksheets {
+        name := e.GraphName(ws)
+        d.Default.Add(wb, edge+"worksheet", name)
+        d.Default.Add(name, rdf.RDFType, node+"Worksheet")
+        d.Default.Add(name, rdf.RDFS+"label", rdf.NewLiteral(ws.Name))
+        d.Default.Add(name, edge+"index", rdf.NewValueLiteral(ws.Index))
+        d.Default.Add(name, edge+"accuracy", rdf.NewValueLiteral(ws.PValidCells))
+        d.Default.Add(name, edge+"validcells", rdf.NewValueLiteral(ws.NValidCells))
+        d.Default.Add(name, edge+"cells", rdf.NewValueLiteral(ws.NCells))
+        d.Default.Add(name, edge+"warnings", rdf.NewValueLiteral(ws.Report.Count(importer.SeverityWarning)))
+        d.Default.Add(name, edge+"errors", rdf.NewValueLiteral(ws.Report.Count(importer.SeverityError)))
+        d.Default.Add(name, edge+"info", rdf.NewValueLiteral(ws.Report.Count(importer.SeverityInfo)))
+    }
+
+    for _, ws := range e.Worksheets {
+        if len(ws.Graph) == 0 {
+            continue
+        }
+
+        elements := sortedElements(ws.Elements)
+        g := d.Graph(e.GraphName(ws))
+        for i, row := range ws.Graph {
+            subject, err := e.Subject(ws, i)
+            if err != nil {
+                return nil, fmt.Errorf("%v `%s`: %v", ErrBuildingGraph, ws.Name, err)
+            }
+            s := rdf.IRI(subject)
+            for _, el := range elements {
+                v, ok := row[el.Name]
+                if !ok {
+                    g.Add(s, e.Property(el), empty)
+                    continue
+                }
+
+                if items, ok := v.(importer.Items); ok {
+                    for _, item := range items {
+                        lit, err := Literal(el, item)
+                        if err != nil {
+                            return nil, fmt.Errorf("%v `%s`: %v", ErrBuildingGraph, ws.Name, err)
+                        }
+                        o := e.Item(el, lit)
+                        g.Add(s, e.Property(el), o)
+                        g.Add(o, rdf.RDFType, node+rdf.IRI(el.Name))
+                        g.Add(o, rdf.RDFS+"label", lit)
+                    }
+                    continue
+                }
+
+                lit, err := Literal(el, v)
+                if err != nil {
+                    return nil, fmt.Errorf("%v `%s`: %v", ErrBuildingGraph, ws.Name, err)
+                }
+                g.Add(s, e.Property(el), lit)
+            }
+        }
+    }
+
+    return d, nil
+}
+
+// Context returns the JSON-LD context of the hazop elements, every element
+// name is a term of its hazopedge property coerced to the element data type
+// or to an IRI for split elements.
+// Lower case terms describe the workbook and its worksheets. JSON-LD
+// graphs reference the context by ContextIRI if set.
+func (e *Exporter) Context() *rdf.Context {
+    c := &rdf.Context{
+        IRI:      rdf.IRI(e.ContextIRI),
+        Prefixes: map[string]rdf.IRI{"xsd": rdf.XSD, "rdfs": rdf.RDFS},
+    }
+    for _, ns := range []string{"hazopnode", "hazopedge", "hazoperro"} {
+        c.Prefixes[ns] = e.Namespace(ns)
+    }
+
+    edge := e.Namespace("hazopedge")
+    c.Terms = append(c.Terms,
+        rdf.ContextTerm{Name: "label", Id: rdf.RDFS + "label"},
+        rdf.ContextTerm{Name: "worksheet", Id: edge + "worksheet", Type: rdf.IdType},
+        rdf.ContextTerm{Name: "index", Id: edge + "index", Type: rdf.XSDInteger},
+        rdf.ContextTerm{Name: "accuracy", Id: edge + "accuracy", Type: rdf.XSDDecimal},
+        rdf.ContextTerm{Name: "validcells", Id: edge + "validcells", Type: rdf.XSDInteger},
+        rdf.ContextTerm{Name: "cells", Id: edge + "cells", Type: rdf.XSDInteger},
+        rdf.ContextTerm{Name: "warnings", Id: edge + "warnings", Type: rdf.XSDInteger},
+        rdf.ContextTerm{Name: "errors", Id: edge + "errors", Type: rdf.XSDInteger},
+        rdf.ContextTerm{Name: "info", Id: edge + "info", Type: rdf.XSDInteger},
+    )
+
+    for _, el := range e.contextElements() {
+        t := rdf.ContextTerm{Name: el.Name, Id: e.Property(el)}
+        switch typ := el.Type(); {
+        case el.Split != "":
+            t.Type = rdf.IdType
+        case typ == importer.IntegerType:
+            t.Type = rdf.XSDInteger
+        case typ == importer.FloatType:
+            t.Type = rdf.XSDDecimal
+        case typ == importer.BooleanType:
+            t.Type = rdf.XSDBoolean
+        case typ == importer.DateType:
+            t.Type = rdf.XSDDate
+        case typ == importer.DateTimeType:
+            t.Type = rdf.XSDDateTime
+        default:
+            t.Lang = el.Lang
+        }
+        c.Terms = append(c.Terms, t)
+    }
+
+    return c
+}
+
+// sortedElements returns the elements of a worksheet in ascending id order.
+func sortedElements(m map[int]importer.HazopElement) []importer.HazopElement {
+    elements := make([]importer.HazopElement, 0, len(m))
+    for _, el := range m {
+        elements = append(elements, el)
+    }
+    sort.Slice(elements, func(i, j int) bool {
+        return elements[i].Id < elements[j].Id
+    })
+    return elements
+}
+
+// contextElements returns the elements of all worksheets in ascending id
+// order, an element of several worksheets is described by the first.
+func (e *Exporter) contextElements() []importer.HazopElement {
+    m := make(map[int]importer.HazopElement)
+    for _, ws := range e.Worksheets {
+        for k, el := range ws.Elements {
+            if _, ok := m[k]; !ok {
+                m[k] = el
+            }
+        }
+    }
+    return sortedElements(m)
+}
+
+// Literal returns the parsed value of the hazop element as RDF literal.
+// Integer, float, boolean, date and datetime elements are typed as
+// xsd:integer, xsd:decimal, xsd:boolean, xsd:date and xsd:dateTime, string
+// elements and reference lists get the language tag of the element if
+// configured. Date times parsed with a zone keep their offset.
+func Literal(el importer.HazopElement, value interface{}) (rdf.Literal, error) {
+    switch v := value.(type) {
+    case []string:
+        return Literal(el, strings.Join(v, ", "))
+    case time.Time:
+        if el.Type() == importer.DateTimeType {
+            layout := "2006-01-02T15:04:05"
+            if v.Location() != time.UTC {
+                layout = time.RFC3339
+            }
+            return rdf.NewTypedLiteral(v.Format(layout), rdf.XSDDateTime), nil
+        }
+        return rdf.NewTypedLiteral(v.Format("2006-01-02"), rdf.XSDDate), nil
+    case string:
+        if el.Lang != "" {
+            return rdf.NewLangLiteral(v, el.Lang)
+        }
+        return rdf.NewLiteral(v), nil
+    default:
+        return rdf.NewValueLiteral(value), nil
+    }
+}
+
+// ExportGraph writes the dataset to fpath in the format given by the file
+// extension, see GraphWriters.
+func (e *Exporter) ExportGraph(fpath string) error {
+    write, ok := GraphWriters[filepath.Ext(fpath)]
+    if !ok {
+        return fmt.Errorf("%v `%s`", ErrUnknownGraphFormat, fpath)
+    }
+
+    d, err := e.Dataset()
+    if err != nil {
+        return err
+    }
+
+    return writeFile(fpath, func(w io.Writer) error {
+        return write(w, d, e.Context())
+    })
+}
+
+// ExportContext writes the JSON-LD context of the hazop elements to fpath,
+// the document to publish at ContextIRI.
+func (e *Exporter) ExportContext(fpath string) error {
+    return writeFile(fpath, func(w io.Writer) error {
+        return rdf.WriteJSONLDContext(w, e.Context())
+    })
+}
+
+func writeFile(fpath string, write func(io.Writer) error) error {
+    f, err := os.Create(fpath)
+    if err != nil {
+        return fmt.Errorf("%v `%s`: %v", ErrCreatingOutputFile, fpath, err)
+    }
+    defer f.Close()
+
+    w := bufio.NewWriter(f)
+    if err := write(w); err != nil {
+        return err
+    }
+
+    return w.Flush()
+}
@@ -0,0 +1,375 @@
+package exporter
+
+import (
+    "bytes"
+    "context"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/dimakdev/HAZOP2RDF2/pkg/rdf"
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func newGraphExporter() *Exporter {
+    return &Exporter{
+        BaseUri:  "http://x",
+        Workbook: "Hazop.xlsx",
+        Worksheets: []*importer.Worksheet{
+            {
+                Elements: map[int]importer.HazopElement{
+                    2:  {Id: 2, Name: "Reference", DataType: "1"},
+                    6:  {Id: 6, Name: "Cause", DataType: "0", Lang: "en"},
+                    13: {Id: 13, Name: "Probability", DataType: "2"},
+                },
+                Index:       2,
+                Name:        "Analysis",
+                HeaderRow:   1,
+                NCells:      6,
+                NValidCells: 4,
+                PValidCells: 66.67,
+                Report:      &importer.Report{Diagnostics: []importer.Diagnostic{{Severity: importer.SeverityError, Message: "Error"}}},
+                Graph: []map[string]interface{}{
+                    {"Reference": 1, "Cause": "Valve \"V1\"\nleft open", "Probability": 0.5},
+                    {"Cause": "Pump failure"},
+                },
+            },
+        },
+    }
+}
+
+func TestDataset(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := newGraphExporter()
+    d, err := exp.Dataset()
+    assert.Empty(err)
+    assert.Equal(18, d.Len())
+    assert.Equal([]rdf.Term{rdf.IRI("http://x/hazopnode/Hazop/Analysis")}, d.Names)
+
+    var b bytes.Buffer
+    assert.Empty(rdf.WriteTriG(&b, d))
+    assert.Equal(`@prefix hazopedge: <http://x/hazopedge#> .
+@prefix hazoperro: <http://x/hazoperro#> .
+@prefix hazopnode: <http://x/hazopnode#> .
+@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+
+<http://x/hazopnode/Hazop> a hazopnode:Workbook ;
+	rdfs:label "Hazop.xlsx" ;
+	hazopedge:worksheet <http://x/hazopnode/Hazop/Analysis> .
+
+<http://x/hazopnode/Hazop/Analysis> a hazopnode:Worksheet ;
+	rdfs:label "Analysis" ;
+	hazopedge:index "2"^^xsd:integer ;
+	hazopedge:accuracy "66.67"^^xsd:decimal ;
+	hazopedge:validcells "4"^^xsd:integer ;
+	hazopedge:cells "6"^^xsd:integer ;
+	hazopedge:warnings "0"^^xsd:integer ;
+	hazopedge:errors "1"^^xsd:integer ;
+	hazopedge:info "0"^^xsd:integer .
+
+<http://x/hazopnode/Hazop/Analysis> {
+	<http://x/hazopnode/Hazop/Analysis/2> hazopedge:reference "1"^^xsd:integer ;
+		hazopedge:cause "Valve \"V1\"\nleft open"@en ;
+		hazopedge:probability "0.5"^^xsd:decimal .
+
+	<http://x/hazopnode/Hazop/Analysis/3> hazopedge:reference hazoperro:empty ;
+		hazopedge:cause "Pump failure"@en ;
+		hazopedge:probability hazoperro:empty .
+}
+`, b.String())
+
+    cause := exp.Worksheets[0].Elements[6]
+    cause.Lang = "en us"
+    exp.Worksheets[0].Elements[6] = cause
+    _, err = exp.Dataset()
+    assert.Error(err)
+}
+
+func TestDatasetItems(t *testing.T) {
+    assert := assert.New(t)
+
+    safeguard := importer.HazopElement{Id: 8, Name: "Safeguard", Lang: "en", Split: "list"}
+    exp := &Exporter{
+        BaseUri:  "http://x",
+        Workbook: "Hazop.xlsx",
+        Worksheets: []*importer.Worksheet{
+            {
+                Index:    1,
+                Name:     "Analysis",
+                Report:   &importer.Report{},
+                Elements: map[int]importer.HazopElement{8: safeguard},
+                Graph: []map[string]interface{}{
+                    {"Safeguard": importer.Items{"PSV-101", "High level alarm"}},
+                    {"Safeguard": importer.Items{"psv-101"}},
+                },
+            },
+        },
+    }
+
+    psv := exp.Item(safeguard, rdf.NewLiteral("PSV-101"))
+    assert.Equal(psv, exp.Item(safeguard, rdf.NewLiteral(" psv-101")))
+    assert.NotEqual(psv, exp.Item(safeguard, rdf.NewLiteral("PSV-102")))
+    assert.Regexp(`^http://x/hazopnode/safeguard/[0-9a-f]{16}$`, psv)
+
+    d, err := exp.Dataset()
+    assert.Empty(err)
+
+    var b bytes.Buffer
+    assert.Empty(rdf.WriteNQuads(&b, d))
+    g := "<http://x/hazopnode/Hazop/Analysis>"
+    for _, quad := range []string{
+        "<http://x/hazopnode/Hazop/Analysis/1> <http://x/hazopedge#safeguard> " + psv.NTriples() + " " + g + " .\n",
+        "<http://x/hazopnode/Hazop/Analysis/2> <http://x/hazopedge#safeguard> " + psv.NTriples() + " " + g + " .\n",
+        psv.NTriples() + " <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://x/hazopnode#Safeguard> " + g + " .\n",
+        psv.NTriples() + ` <http://www.w3.org/2000/01/rdf-schema#label> "PSV-101"@en ` + g + " .\n",
+        psv.NTriples() + ` <http://www.w3.org/2000/01/rdf-schema#label> "psv-101"@en ` + g + " .\n",
+    } {
+        assert.Contains(b.String(), quad)
+    }
+
+    terms := exp.Context().Terms
+    assert.Equal(rdf.ContextTerm{Name: "Safeguard", Id: "http://x/hazopedge#safeguard", Type: rdf.IdType}, terms[len(terms)-1])
+}
+
+func TestDatasetOverrides(t *testing.T) {
+    assert := assert.New(t)
+
+    split := "semicolons"
+    hazop := &importer.HazopElements{
+        Elements: []importer.HazopElement{
+            {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", DataType: "string", MaxLen: 80},
+            {Id: 6, Name: "Cause", Regex: "^(?i)(cause)", DataType: "string", MaxLen: 80},
+            {Id: 8, Name: "Safeguard", Regex: "^(?i)(safeguard)", DataType: "string", MaxLen: 80},
+        },
+        Overrides: []importer.Override{
+            {Sheet: "^Node2$", Elements: []importer.ElementOverride{{Id: 6, Skip: true}, {Id: 8, Split: &split}}},
+        },
+    }
+
+    f := excelize.NewFile()
+    f.SetSheetName("Sheet1", "Node1")
+    f.NewSheet("Node2")
+    for _, sheet := range []string{"Node1", "Node2"} {
+        f.SetSheetRow(sheet, "A1", &[]interface{}{"Deviation", "Cause", "Safeguard"})
+        f.SetSheetRow(sheet, "A2", &[]interface{}{"No flow", "Pump fails", "Alarm; Relief valve"})
+    }
+    fpath := filepath.Join(t.TempDir(), "Hazop.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := importer.ImportWorkbookContext(context.Background(), fpath, importer.Options{Hazop: hazop})
+    assert.Empty(err)
+    defer wb.File.Close()
+
+    exp := &Exporter{BaseUri: "http://x", Workbook: "Hazop.xlsx", Worksheets: wb.Worksheets}
+    d, err := exp.Dataset()
+    assert.Empty(err)
+
+    var b bytes.Buffer
+    assert.Empty(rdf.WriteNQuads(&b, d))
+    var node1, node2 []string
+    for _, quad := range strings.Split(b.String(), "\n") {
+        switch {
+        case strings.HasSuffix(quad, "<http://x/hazopnode/Hazop/Node1> ."):
+            node1 = append(node1, quad)
+        case strings.HasSuffix(quad, "<http://x/hazopnode/Hazop/Node2> ."):
+            node2 = append(node2, quad)
+        }
+    }
+
+    safeguard := wb.Worksheets[1].Elements[8]
+    assert.Contains(node1, `<http://x/hazopnode/Hazop/Node1/2> <http://x/hazopedge#cause> "Pump fails" <http://x/hazopnode/Hazop/Node1> .`)
+    assert.Contains(node1, `<http://x/hazopnode/Hazop/Node1/2> <http://x/hazopedge#safeguard> "Alarm; Relief valve" <http://x/hazopnode/Hazop/Node1> .`)
+    assert.NotContains(strings.Join(node2, "\n"), "hazopedge#cause")
+    for _, item := range []string{"Alarm", "Relief valve"} {
+        assert.Contains(node2, "<http://x/hazopnode/Hazop/Node2/2> <http://x/hazopedge#safeguard> "+exp.Item(safeguard, rdf.NewLiteral(item)).NTriples()+" <http://x/hazopnode/Hazop/Node2> .")
+    }
+}
+
+func TestLiteral(t *testing.T) {
+    assert := assert.New(t)
+
+    d := time.Date(2021, 3, 4, 13, 45, 0, 0, time.UTC)
+    for _, tc := range []struct {
+        element importer.HazopElement
+        value   interface{}
+        want    rdf.Literal
+    }{
+        {importer.HazopElement{DataType: "date"}, d, rdf.NewTypedLiteral("2021-03-04", rdf.XSDDate)},
+        {importer.HazopElement{DataType: "datetime"}, d, rdf.NewTypedLiteral("2021-03-04T13:45:00", rdf.XSDDateTime)},
+        {importer.HazopElement{DataType: "datetime"}, d.In(time.FixedZone("", 3600)), rdf.NewTypedLiteral("2021-03-04T14:45:00+01:00", rdf.XSDDateTime)},
+        {importer.HazopElement{DataType: "datetime"}, d.In(time.FixedZone("UTC", 0)), rdf.NewTypedLiteral("2021-03-04T13:45:00Z", rdf.XSDDateTime)},
+        {importer.HazopElement{DataType: "boolean"}, true, rdf.NewTypedLiteral("true", rdf.XSDBoolean)},
+        {importer.HazopElement{DataType: "reference-list"}, []string{"1.1", "1.2"}, rdf.NewLiteral("1.1, 1.2")},
+        {importer.HazopElement{DataType: "float"}, 0.25, rdf.NewTypedLiteral("0.25", rdf.XSDDecimal)},
+    } {
+        lit, err := Literal(tc.element, tc.value)
+        assert.Empty(err)
+        assert.Equal(tc.want, lit)
+    }
+}
+
+func TestContext(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := newGraphExporter()
+    d, err := exp.Dataset()
+    assert.Empty(err)
+
+    var b bytes.Buffer
+    assert.Empty(rdf.WriteJSONLD(&b, d, exp.Context()))
+    assert.Equal(`{
+  "@context": {
+    "hazopedge": "http://x/hazopedge#",
+    "hazoperro": "http://x/hazoperro#",
+    "hazopnode": "http://x/hazopnode#",
+    "rdfs": "http://www.w3.org/2000/01/rdf-schema#",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "label": {
+      "@id": "rdfs:label"
+    },
+    "worksheet": {
+      "@id": "hazopedge:worksheet",
+      "@type": "@id"
+    },
+    "index": {
+      "@id": "hazopedge:index",
+      "@type": "xsd:integer"
+    },
+    "accuracy": {
+      "@id": "hazopedge:accuracy",
+      "@type": "xsd:decimal"
+    },
+    "validcells": {
+      "@id": "hazopedge:validcells",
+      "@type": "xsd:integer"
+    },
+    "cells": {
+      "@id": "hazopedge:cells",
+      "@type": "xsd:integer"
+    },
+    "warnings": {
+      "@id": "hazopedge:warnings",
+      "@type": "xsd:integer"
+    },
+    "errors": {
+      "@id": "hazopedge:errors",
+      "@type": "xsd:integer"
+    },
+    "info": {
+      "@id": "hazopedge:info",
+      "@type": "xsd:integer"
+    },
+    "Reference": {
+      "@id": "hazopedge:reference",
+      "@type": "xsd:integer"
+    },
+    "Cause": {
+      "@id": "hazopedge:cause",
+      "@language": "en"
+    },
+    "Probability": {
+      "@id": "hazopedge:probability",
+      "@type": "xsd:decimal"
+    }
+  },
+  "@graph": [
+    {
+      "@id": "http://x/hazopnode/Hazop",
+      "@type": "hazopnode:Workbook",
+      "label": "Hazop.xlsx",
+      "worksheet": "http://x/hazopnode/Hazop/Analysis"
+    },
+    {
+      "@id": "http://x/hazopnode/Hazop/Analysis",
+      "@type": "hazopnode:Worksheet",
+      "label": "Analysis",
+      "index": "2",
+      "accuracy": "66.67",
+      "validcells": "4",
+      "cells": "6",
+      "warnings": "0",
+      "errors": "1",
+      "info": "0",
+      "@graph": [
+        {
+          "@id": "http://x/hazopnode/Hazop/Analysis/2",
+          "Reference": "1",
+          "Cause": "Valve \"V1\"\nleft open",
+          "Probability": "0.5"
+        },
+        {
+          "@id": "http://x/hazopnode/Hazop/Analysis/3",
+          "Reference": {
+            "@id": "hazoperro:empty"
+          },
+          "Cause": "Pump failure",
+          "Probability": {
+            "@id": "hazoperro:empty"
+          }
+        }
+      ]
+    }
+  ]
+}
+`, b.String())
+}
+
+func TestExportGraph(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := newGraphExporter()
+
+    var err error
+
+    err = exp.ExportGraph("graph_file.txt")
+    assert.Error(err)
+
+    for _, gpath := range []string{"graph_file.ttl", "graph_file.nt", "graph_file.jsonld", "graph_file.trig", "graph_file.nq"} {
+        err = exp.ExportGraph(gpath)
+        assert.Empty(err)
+
+        err = os.Remove(gpath)
+        assert.Empty(err)
+    }
+
+    err = exp.ExportContext("context.jsonld")
+    assert.Empty(err)
+
+    err = os.Remove("context.jsonld")
+    assert.Empty(err)
+
+    exp.ContextIRI = "graph_file.context.jsonld"
+    err = exp.ExportGraph("graph_file.jsonld")
+    assert.Empty(err)
+
+    b, err := ioutil.ReadFile("graph_file.jsonld")
+    assert.Empty(err)
+    assert.Contains(string(b), `"@context": "graph_file.context.jsonld",`)
+
+    err = os.Remove("graph_file.jsonld")
+    assert.Empty(err)
+}
+
+func TestExportGraphReproducible(t *testing.T) {
+    assert := assert.New(t)
+
+    for _, gpath := range []string{"graph_file.ttl", "graph_file.jsonld", "graph_file.trig"} {
+        var out [][]byte
+        for i := 0; i < 3; i++ {
+            assert.Empty(newGraphExporter().ExportGraph(gpath))
+            b, err := ioutil.ReadFile(gpath)
+            assert.Empty(err)
+            out = append(out, b)
+        }
+        assert.Equal(out[0], out[1])
+        assert.Equal(out[0], out[2])
+        assert.Empty(os.Remove(gpath))
+    }
+}
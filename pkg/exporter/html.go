@@ -0,0 +1,197 @@
+package exporter
+
+import (
+    _ "embed"
+    "fmt"
+    "html/template"
+    "io"
+    "sort"
+    "strings"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/xuri/excelize/v2"
+)
+
+//go:embed report_template.html
+var htmlTemplateText string
+
+// htmlTemplate renders the self-contained HTML report, styles and scripts
+// are inlined so the report works offline.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+    "percent": func(f float64) string { return fmt.Sprintf("%.2f", f) },
+}).Parse(htmlTemplateText))
+
+// htmlReport is the workbook summary of the HTML report.
+type htmlReport struct {
+    *Exporter
+    Sheets      []htmlSheet
+    NCells      int
+    NValidCells int
+    PValidCells float64
+    Errors      int
+    Warnings    int
+    Info        int
+}
+
+// htmlSheet is a worksheet of the HTML report with its header map, parsed
+// rows and diagnostics.
+type htmlSheet struct {
+    *importer.Worksheet
+    Anchor      string
+    Errors      int
+    Warnings    int
+    Info        int
+    HeaderMap   []htmlHeader
+    Columns     []string
+    Rows        []htmlRow
+    Diagnostics []htmlDiagnostic
+    Codes       []string
+}
+
+// htmlHeader is the header cell of an element, empty if not found.
+type htmlHeader struct {
+    Element string
+    Cell    string
+    Path    string
+}
+
+type htmlRow struct {
+    Row   int
+    Cells []htmlCell
+}
+
+// htmlCell is a cell of the parsed table. Class is the highest severity of
+// its diagnostics or inherited, Title lists their messages.
+type htmlCell struct {
+    Cell  string
+    Value string
+    Class string
+    Title string
+}
+
+type htmlDiagnostic struct {
+    importer.Diagnostic
+    ElementName string
+}
+
+// WriteHTML writes a self-contained HTML report with a workbook summary and
+// for every worksheet its accuracy, header map, parsed table with marked
+// cells and filterable diagnostics.
+func WriteHTML(w io.Writer, e *Exporter) error {
+    r := htmlReport{Exporter: e}
+    for i, ws := range e.Worksheets {
+        s, err := newHTMLSheet(ws, i)
+        if err != nil {
+            return err
+        }
+        r.Sheets = append(r.Sheets, s)
+        r.Errors += s.Errors
+        r.Warnings += s.Warnings
+        r.Info += s.Info
+    }
+    r.NValidCells, r.NCells, r.PValidCells = e.Accuracy()
+
+    return htmlTemplate.Execute(w, r)
+}
+
+func newHTMLSheet(ws *importer.Worksheet, i int) (htmlSheet, error) {
+    s := htmlSheet{
+        Worksheet: ws,
+        Anchor:    fmt.Sprintf("ws%d", i+1),
+        Errors:    ws.Report.Count(importer.SeverityError),
+        Warnings:  ws.Report.Count(importer.SeverityWarning),
+        Info:      ws.Report.Count(importer.SeverityInfo),
+    }
+
+    for _, k := range ws.ElementIds() {
+        s.HeaderMap = append(s.HeaderMap, htmlHeader{
+            Element: ws.Elements[k].Name,
+            Cell:    ws.Headers[k],
+            Path:    ws.HeaderPaths[k],
+        })
+    }
+
+    codes := make(map[string]bool)
+    cells := make(map[string][]importer.Diagnostic)
+    for _, d := range ws.Report.Diagnostics {
+        if d.Cell != "" {
+            cells[d.Cell] = append(cells[d.Cell], d)
+        }
+        hd := htmlDiagnostic{Diagnostic: d}
+        if d.Element != nil {
+            hd.ElementName = ws.Elements[*d.Element].Name
+        }
+        s.Diagnostics = append(s.Diagnostics, hd)
+        if !codes[d.Code] {
+            codes[d.Code] = true
+            s.Codes = append(s.Codes, d.Code)
+        }
+    }
+    sort.Strings(s.Codes)
+
+    ids := ws.HeaderIds()
+    for _, k := range ids {
+        s.Columns = append(s.Columns, ws.Elements[k].Name)
+    }
+
+    for i, row := range ws.Graph {
+        hr := htmlRow{Row: ws.Row(i)}
+        for _, k := range ids {
+            el := ws.Elements[k]
+            cname, err := excelize.CoordinatesToCellName(ws.HeaderX[k], hr.Row)
+            if err != nil {
+                return s, err
+            }
+
+            c := htmlCell{Cell: cname}
+            if v, ok := row[el.Name]; ok {
+                c.Value = formatValue(el, v)
+            }
+            if _, ok := ws.Inherited[cname]; ok {
+                c.Class = "inherited"
+            }
+
+            var msgs []string
+            for _, d := range cells[cname] {
+                switch d.Severity {
+                case importer.SeverityError:
+                    c.Class = "error"
+                case importer.SeverityWarning:
+                    if c.Class != "error" {
+                        c.Class = "warning"
+                    }
+                default:
+                    continue
+                }
+                if c.Value == "" {
+                    c.Value = d.Value
+                }
+                msgs = append(msgs, d.Message)
+            }
+            c.Title = strings.Join(msgs, "\n")
+
+            hr.Cells = append(hr.Cells, c)
+        }
+        s.Rows = append(s.Rows, hr)
+    }
+
+    return s, nil
+}
+
+// formatValue returns the parsed value as written in the graph, items of
+// split cells are joined by semicolons.
+func formatValue(el importer.HazopElement, v interface{}) string {
+    if items, ok := v.(importer.Items); ok {
+        values := make([]string, 0, len(items))
+        for _, item := range items {
+            values = append(values, formatValue(el, item))
+        }
+        return strings.Join(values, "; ")
+    }
+
+    lit, err := Literal(el, v)
+    if err != nil {
+        return fmt.Sprint(v)
+    }
+    return lit.Value
+}
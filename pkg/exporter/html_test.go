@@ -0,0 +1,81 @@
+package exporter
+
+import (
+    "bytes"
+    "fmt"
+    "io/ioutil"
+    "testing"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestWriteHTML(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := &Exporter{AppName: "HAZOP2RDF2", Workbook: "Hazop.xlsx", Worksheets: []*importer.Worksheet{reportWorksheet()}}
+
+    var buf bytes.Buffer
+    assert.Empty(WriteHTML(&buf, exp))
+
+    html := buf.String()
+    assert.Contains(html, `<span style="width: 75.00%">`)
+    assert.Contains(html, `<a href="#ws1">1. Node &lt;1&gt;</a>`)
+    assert.Contains(html, `<tr class="error"><td>Deviation</td><td></td><td>not found</td></tr>`)
+    assert.Contains(html, `<td data-cell="B2">PSV-101; LAH-12</td>`)
+    assert.Contains(html, `<td data-cell="B3" class="inherited">PSV-101; LAH-12</td>`)
+    assert.Contains(html, `<td data-cell="A3" class="error" title="Error parsing integer `+"`A3`"+`">one</td>`)
+    assert.Contains(html, `data-code="parsing-integer" data-cell="A3"`)
+    assert.NotContains(html, "<link")
+    assert.NotContains(html, "src=")
+}
+
+// largeWorksheet returns a worksheet of n rows with three columns and an
+// error in every other cell of the first column.
+func largeWorksheet(n int) *importer.Worksheet {
+    ws := &importer.Worksheet{
+        Index:     1,
+        Name:      "Analysis",
+        HeaderRow: 1,
+        Elements: map[int]importer.HazopElement{
+            2: {Id: 2, Name: "Reference", DataType: "integer"},
+            5: {Id: 5, Name: "Deviation", DataType: "string"},
+            6: {Id: 6, Name: "Cause", DataType: "string"},
+        },
+        Headers: map[int]string{2: "A1", 5: "B1", 6: "C1"},
+        HeaderX: map[int]int{2: 1, 5: 2, 6: 3},
+        Report:  &importer.Report{},
+    }
+
+    for i := 0; i < n; i++ {
+        ws.Graph = append(ws.Graph, map[string]interface{}{"Deviation": "No flow", "Cause": fmt.Sprintf("Pump %d fails", i)})
+        if i%2 == 0 {
+            cell, _ := excelize.CoordinatesToCellName(1, ws.Row(i))
+            ws.Report.Add(importer.Diagnostic{
+                Code:     importer.CodeParsingInteger,
+                Severity: importer.SeverityError,
+                Cell:     cell,
+                Value:    "one",
+                Message:  "Error parsing integer `" + cell + "`",
+            })
+        }
+    }
+
+    return ws
+}
+
+func BenchmarkWriteHTML(b *testing.B) {
+    for _, n := range []int{1000, 10000} {
+        exp := &Exporter{AppName: "HAZOP2RDF2", Workbook: "Hazop.xlsx", Worksheets: []*importer.Worksheet{largeWorksheet(n)}}
+
+        b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+            b.ReportAllocs()
+            for i := 0; i < b.N; i++ {
+                if err := WriteHTML(ioutil.Discard, exp); err != nil {
+                    b.Fatal(err)
+                }
+            }
+        })
+    }
+}
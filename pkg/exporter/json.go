@@ -0,0 +1,112 @@
+package exporter
+
+import (
+    "encoding/json"
+    "io"
+    "math"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+)
+
+type jsonReport struct {
+    Program     string          `json:"program"`
+    Version     string          `json:"version"`
+    DateTime    string          `json:"date_time"`
+    Workbook    string          `json:"workbook"`
+    PValidCells float64         `json:"p_valid_cells"`
+    NValidCells int             `json:"n_valid_cells"`
+    NCells      int             `json:"n_cells"`
+    Errors      int             `json:"errors"`
+    Warnings    int             `json:"warnings"`
+    Info        int             `json:"info"`
+    Worksheets  []jsonWorksheet `json:"worksheets"`
+}
+
+type jsonWorksheet struct {
+    Index       int                   `json:"index"`
+    Name        string                `json:"name"`
+    Valid       bool                  `json:"valid"`
+    NRows       int                   `json:"n_rows"`
+    NCols       int                   `json:"n_cols"`
+    PValidCells float64               `json:"p_valid_cells"`
+    NValidCells int                   `json:"n_valid_cells"`
+    NCells      int                   `json:"n_cells"`
+    HeaderRow   int                   `json:"header_row"`
+    GraphNRows  int                   `json:"graph_n_rows"`
+    Errors      int                   `json:"errors"`
+    Warnings    int                   `json:"warnings"`
+    Info        int                   `json:"info"`
+    Headers     []jsonHeader          `json:"headers"`
+    Diagnostics []importer.Diagnostic `json:"diagnostics"`
+}
+
+// jsonHeader is the header of an element, cell and coordinates are omitted
+// if it is not found.
+type jsonHeader struct {
+    Id      int    `json:"id"`
+    Element string `json:"element"`
+    Found   bool   `json:"found"`
+    Cell    string `json:"cell,omitempty"`
+    Path    string `json:"path,omitempty"`
+    X       int    `json:"x,omitempty"`
+    Y       int    `json:"y,omitempty"`
+}
+
+// WriteJSON writes the workbook, its worksheets with their headers and all
+// diagnostics as indented JSON. Accuracies are rounded to two decimals.
+func WriteJSON(w io.Writer, e *Exporter) error {
+    r := jsonReport{
+        Program:    e.AppName,
+        Version:    e.AppVersion,
+        DateTime:   e.DateTime,
+        Workbook:   e.Workbook,
+        Worksheets: []jsonWorksheet{},
+    }
+    r.NValidCells, r.NCells, r.PValidCells = e.Accuracy()
+    r.PValidCells = math.Round(r.PValidCells*100) / 100
+
+    for _, ws := range e.Worksheets {
+        jws := jsonWorksheet{
+            Index:       ws.Index,
+            Name:        ws.Name,
+            Valid:       ws.IsValid,
+            NRows:       ws.NRows,
+            NCols:       ws.NCols,
+            PValidCells: ws.PValidCells,
+            NValidCells: ws.NValidCells,
+            NCells:      ws.NCells,
+            HeaderRow:   ws.HeaderRow,
+            GraphNRows:  ws.GraphNRows,
+            Errors:      ws.Report.Count(importer.SeverityError),
+            Warnings:    ws.Report.Count(importer.SeverityWarning),
+            Info:        ws.Report.Count(importer.SeverityInfo),
+            Headers:     []jsonHeader{},
+            Diagnostics: ws.Report.Diagnostics,
+        }
+        if jws.Diagnostics == nil {
+            jws.Diagnostics = []importer.Diagnostic{}
+        }
+
+        for _, k := range ws.ElementIds() {
+            cell, ok := ws.Headers[k]
+            jws.Headers = append(jws.Headers, jsonHeader{
+                Id:      k,
+                Element: ws.Elements[k].Name,
+                Found:   ok,
+                Cell:    cell,
+                Path:    ws.HeaderPaths[k],
+                X:       ws.HeaderX[k],
+                Y:       ws.HeaderY[k],
+            })
+        }
+
+        r.Errors += jws.Errors
+        r.Warnings += jws.Warnings
+        r.Info += jws.Info
+        r.Worksheets = append(r.Worksheets, jws)
+    }
+
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(r)
+}
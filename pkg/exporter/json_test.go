@@ -0,0 +1,49 @@
+package exporter
+
+import (
+    "bytes"
+    "encoding/json"
+    "testing"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSON(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := &Exporter{AppName: "HAZOP2RDF2", Workbook: "Hazop.xlsx", Worksheets: []*importer.Worksheet{reportWorksheet()}}
+
+    var buf bytes.Buffer
+    assert.Empty(WriteJSON(&buf, exp))
+
+    var r jsonReport
+    assert.Empty(json.Unmarshal(buf.Bytes(), &r))
+    assert.Equal("Hazop.xlsx", r.Workbook)
+    assert.Equal(75.0, r.PValidCells)
+    assert.Equal(2, r.Errors)
+    assert.Len(r.Worksheets, 1)
+
+    ws := r.Worksheets[0]
+    assert.Equal("Node <1>", ws.Name)
+    assert.Equal(3, ws.NValidCells)
+    assert.Equal([]jsonHeader{
+        {Id: 2, Element: "Reference", Found: true, Cell: "A1", Path: "Ref", X: 1},
+        {Id: 5, Element: "Deviation"},
+        {Id: 8, Element: "Safeguard", Found: true, Cell: "B1", Path: "Safeguard", X: 2},
+    }, ws.Headers)
+    assert.Len(ws.Diagnostics, 5)
+    assert.Equal(importer.CodeParsingInteger, ws.Diagnostics[4].Code)
+    assert.Equal(2, *ws.Diagnostics[4].Element)
+    assert.Equal("one", ws.Diagnostics[4].Value)
+
+    buf.Reset()
+    exp.Worksheets = append(exp.Worksheets, &importer.Worksheet{Name: "Node 2", NValidCells: 1, NCells: 2, PValidCells: 50, Report: &importer.Report{}})
+    assert.Empty(WriteJSON(&buf, exp))
+    assert.Empty(json.Unmarshal(buf.Bytes(), &r))
+    assert.Equal(66.67, r.PValidCells)
+
+    buf.Reset()
+    assert.Empty(WriteJSON(&buf, &Exporter{}))
+    assert.Contains(buf.String(), `"worksheets": []`)
+}
@@ -0,0 +1,143 @@
+package exporter
+
+import (
+    "encoding/xml"
+    "fmt"
+    "io"
+    "strings"
+    "time"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+)
+
+type junitTestSuites struct {
+    XMLName  xml.Name         `xml:"testsuites"`
+    Name     string           `xml:"name,attr"`
+    Tests    int              `xml:"tests,attr"`
+    Failures int              `xml:"failures,attr"`
+    Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+    Name      string          `xml:"name,attr"`
+    Tests     int             `xml:"tests,attr"`
+    Failures  int             `xml:"failures,attr"`
+    Timestamp string          `xml:"timestamp,attr,omitempty"`
+    Cases     []junitTestCase `xml:"testcase"`
+    SystemOut string          `xml:"system-out,omitempty"`
+}
+
+// junitDateTimes are the accepted layouts of the exporter date and time.
+var junitDateTimes = []string{
+    time.UnixDate,
+    time.RFC3339,
+    "2006-01-02T15:04:05",
+    "2006-01-02 15:04:05",
+    "2006-01-02",
+}
+
+// junitTimestamp returns the date and time in the ISO 8601 format of JUnit
+// timestamps, e.g. `2021-03-04T13:45:00`, or an empty string if it does not
+// parse.
+func junitTimestamp(dateTime string) string {
+    for _, layout := range junitDateTimes {
+        if d, err := time.Parse(layout, dateTime); err == nil {
+            return d.Format("2006-01-02T15:04:05")
+        }
+    }
+    return ""
+}
+
+type junitTestCase struct {
+    Name      string        `xml:"name,attr"`
+    Classname string        `xml:"classname,attr"`
+    Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+    Message string `xml:"message,attr"`
+    Type    string `xml:"type,attr"`
+    Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes a JUnit XML report, every worksheet is a testsuite with
+// a testcase per header and per cell check. Missing headers and invalid
+// cells fail, warnings are written to the system-out of their testsuite.
+// The timestamp is left out if the exporter date and time does not parse.
+func WriteJUnit(w io.Writer, e *Exporter) error {
+    r := junitTestSuites{Name: e.Workbook}
+    timestamp := junitTimestamp(e.DateTime)
+    for _, ws := range e.Worksheets {
+        s := junitTestSuite{Name: ws.Name, Timestamp: timestamp}
+        classname := e.Workbook + "." + ws.Name
+
+        for _, k := range ws.ElementIds() {
+            el := ws.Elements[k]
+            tc := junitTestCase{Name: "header " + el.Name, Classname: classname + ".headers"}
+            if _, ok := ws.Headers[k]; !ok {
+                tc.Failure = &junitFailure{
+                    Message: fmt.Sprintf("%s `%d:%s`", importer.ErrHeaderNotFound, el.Id, el.Name),
+                    Type:    importer.CodeHeaderNotFound,
+                }
+                for _, d := range ws.Report.Code(importer.CodeHeaderNotFound) {
+                    if d.Element != nil && *d.Element == k {
+                        tc.Failure.Message = d.Message
+                        tc.Failure.Text = d.Expected
+                    }
+                }
+            }
+            s.add(tc)
+        }
+
+        var warnings []string
+        for _, d := range ws.Report.Diagnostics {
+            if d.Severity == importer.SeverityWarning {
+                warnings = append(warnings, d.Message)
+            }
+            if !importer.IsCellCheck(d.Code) {
+                continue
+            }
+
+            var element string
+            if d.Element != nil {
+                element = ws.Elements[*d.Element].Name
+            }
+            tc := junitTestCase{Name: strings.TrimSpace("cell " + d.Cell + " " + element), Classname: classname + ".cells"}
+            if d.Severity == importer.SeverityError {
+                tc.Failure = &junitFailure{
+                    Message: d.Message,
+                    Type:    d.Code,
+                    Text:    fmt.Sprintf("value: %s\nexpected: %s", d.Value, d.Expected),
+                }
+            }
+            s.add(tc)
+        }
+        s.SystemOut = strings.Join(warnings, "\n")
+
+        r.Tests += s.Tests
+        r.Failures += s.Failures
+        r.Suites = append(r.Suites, s)
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil {
+        return err
+    }
+
+    enc := xml.NewEncoder(w)
+    enc.Indent("", "  ")
+    if err := enc.Encode(r); err != nil {
+        return err
+    }
+
+    _, err := io.WriteString(w, "\n")
+    return err
+}
+
+// add appends the testcase and counts it.
+func (s *junitTestSuite) add(tc junitTestCase) {
+    s.Tests += 1
+    if tc.Failure != nil {
+        s.Failures += 1
+    }
+    s.Cases = append(s.Cases, tc)
+}
@@ -0,0 +1,61 @@
+package exporter
+
+import (
+    "bytes"
+    "encoding/xml"
+    "testing"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/stretchr/testify/assert"
+)
+
+func TestWriteJUnit(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := &Exporter{Workbook: "Hazop.xlsx", DateTime: "Thu Mar  4 13:45:00 UTC 2021", Worksheets: []*importer.Worksheet{reportWorksheet()}}
+
+    var buf bytes.Buffer
+    assert.Empty(WriteJUnit(&buf, exp))
+    assert.Contains(buf.String(), xml.Header)
+
+    var r junitTestSuites
+    assert.Empty(xml.Unmarshal(buf.Bytes(), &r))
+    assert.Equal("Hazop.xlsx", r.Name)
+    assert.Equal(5, r.Tests)
+    assert.Equal(2, r.Failures)
+    assert.Len(r.Suites, 1)
+
+    s := r.Suites[0]
+    assert.Equal("Node <1>", s.Name)
+    assert.Equal("2021-03-04T13:45:00", s.Timestamp)
+    assert.Equal("Warning header candidates", s.SystemOut)
+
+    var names []string
+    for _, tc := range s.Cases {
+        names = append(names, tc.Name)
+    }
+    assert.Equal([]string{"header Reference", "header Deviation", "header Safeguard", "cell A2 Reference", "cell A3 Reference"}, names)
+
+    assert.Equal("Hazop.xlsx.Node <1>.headers", s.Cases[1].Classname)
+    assert.Equal(&junitFailure{Message: "Error header not found `5:Deviation` []", Type: importer.CodeHeaderNotFound, Text: "^(?i)(deviation)"}, s.Cases[1].Failure)
+    assert.Nil(s.Cases[3].Failure)
+    assert.Equal("Hazop.xlsx.Node <1>.cells", s.Cases[4].Classname)
+    assert.Equal(importer.CodeParsingInteger, s.Cases[4].Failure.Type)
+    assert.Equal("value: one\nexpected: ", s.Cases[4].Failure.Text)
+}
+
+func TestJUnitTimestamp(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal("2021-03-04T13:45:00", junitTimestamp("Thu Mar  4 13:45:00 UTC 2021"))
+    assert.Equal("2021-03-04T13:45:00", junitTimestamp("2021-03-04T13:45:00+01:00"))
+    assert.Equal("2021-03-04T13:45:00", junitTimestamp("2021-03-04 13:45:00"))
+    assert.Equal("2021-03-04T00:00:00", junitTimestamp("2021-03-04"))
+    assert.Empty(junitTimestamp("today"))
+    assert.Empty(junitTimestamp(""))
+
+    var buf bytes.Buffer
+    assert.Empty(WriteJUnit(&buf, &Exporter{Workbook: "Hazop.xlsx", DateTime: "today", Worksheets: []*importer.Worksheet{reportWorksheet()}}))
+    assert.NotContains(buf.String(), "timestamp=")
+    assert.NotContains(buf.String(), "errors=")
+}
@@ -0,0 +1,47 @@
+package exporter
+
+import (
+    "io"
+    "path/filepath"
+)
+
+// ReportWriter writes the report of the exporter workbook.
+type ReportWriter func(w io.Writer, e *Exporter) error
+
+// ReportWriters maps report file extensions to their writers, reports with
+// other extensions are rendered by a text template.
+var ReportWriters = map[string]ReportWriter{
+    ".html":  WriteHTML,
+    ".json":  WriteJSON,
+    ".xml":   WriteJUnit,
+    ".sarif": WriteSARIF,
+}
+
+// ExportReport writes the report to fpath in the format given by the file
+// extension, see ReportWriters. Other extensions are rendered by the
+// template tpath.
+func (e *Exporter) ExportReport(fpath, tpath string) error {
+    write, ok := ReportWriters[filepath.Ext(fpath)]
+    if !ok {
+        return e.ExportToFile(fpath, tpath)
+    }
+
+    return writeFile(fpath, func(w io.Writer) error {
+        return write(w, e)
+    })
+}
+
+// Accuracy returns the valid and all cells of the worksheets and the
+// percentage of valid cells.
+func (e *Exporter) Accuracy() (int, int, float64) {
+    var nvalid, ncells int
+    for _, ws := range e.Worksheets {
+        nvalid += ws.NValidCells
+        ncells += ws.NCells
+    }
+
+    if ncells == 0 {
+        return nvalid, ncells, 0
+    }
+    return nvalid, ncells, float64(nvalid) / float64(ncells) * 100
+}
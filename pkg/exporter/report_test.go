@@ -0,0 +1,73 @@
+package exporter
+
+import (
+    "io/ioutil"
+    "path/filepath"
+    "testing"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/stretchr/testify/assert"
+)
+
+// reportWorksheet returns a worksheet with a missing header, an inherited
+// and an invalid cell.
+func reportWorksheet() *importer.Worksheet {
+    id, deviation := 2, 5
+    return &importer.Worksheet{
+        Index:       1,
+        Name:        "Node <1>",
+        HeaderRow:   1,
+        NCells:      4,
+        NValidCells: 3,
+        PValidCells: 75,
+        Headers:     map[int]string{2: "A1", 8: "B1"},
+        HeaderPaths: map[int]string{2: "Ref", 8: "Safeguard"},
+        HeaderX:     map[int]int{2: 1, 8: 2},
+        Inherited:   map[string]string{"B3": "B2"},
+        Elements: map[int]importer.HazopElement{
+            2: {Id: 2, Name: "Reference", DataType: "integer"},
+            5: {Id: 5, Name: "Deviation"},
+            8: {Id: 8, Name: "Safeguard", Split: "list"},
+        },
+        Graph: []map[string]interface{}{
+            {"Reference": 1, "Safeguard": importer.Items{"PSV-101", "LAH-12"}},
+            {"Safeguard": importer.Items{"PSV-101", "LAH-12"}},
+        },
+        Report: &importer.Report{Diagnostics: []importer.Diagnostic{
+            {Code: importer.CodeHeaderCandidates, Severity: importer.SeverityWarning, Cell: "A1", Message: "Warning header candidates"},
+            {Code: importer.CodeHeaderNotFound, Severity: importer.SeverityError, Element: &deviation, Expected: "^(?i)(deviation)", Message: "Error header not found `5:Deviation` []"},
+            {Code: importer.CodeValueValid, Severity: importer.SeverityInfo, Cell: "A2", Element: &id, Value: "1", Message: "Info value parsed/verified: `A2`"},
+            {Code: importer.CodeValueInherited, Severity: importer.SeverityInfo, Cell: "B3", Message: "Info value inherited"},
+            {Code: importer.CodeParsingInteger, Severity: importer.SeverityError, Cell: "A3", Element: &id, Value: "one", Message: "Error parsing integer `A3`"},
+        }},
+    }
+}
+
+func TestExportReport(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := &Exporter{AppName: "HAZOP2RDF2", Worksheets: []*importer.Worksheet{reportWorksheet()}}
+    dir := t.TempDir()
+
+    hpath := filepath.Join(dir, "report.html")
+    assert.Empty(exp.ExportReport(hpath, ""))
+    b, err := ioutil.ReadFile(hpath)
+    assert.Empty(err)
+    assert.Contains(string(b), "<!DOCTYPE html>")
+
+    tpath := filepath.Join(dir, "report.txt")
+    assert.Error(exp.ExportReport(tpath, ""))
+    assert.Empty(exp.ExportReport(tpath, "report_template_short.txt"))
+}
+
+func TestAccuracy(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := &Exporter{}
+    nvalid, ncells, p := exp.Accuracy()
+    assert.Equal([]interface{}{0, 0, 0.0}, []interface{}{nvalid, ncells, p})
+
+    exp.Worksheets = []*importer.Worksheet{{NValidCells: 1, NCells: 4}, {NValidCells: 2, NCells: 4}}
+    nvalid, ncells, p = exp.Accuracy()
+    assert.Equal([]interface{}{3, 8, 37.5}, []interface{}{nvalid, ncells, p})
+}
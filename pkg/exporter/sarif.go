@@ -0,0 +1,242 @@
+package exporter
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/url"
+    "path/filepath"
+    "strings"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+)
+
+// SARIFSchema is the JSON schema of SARIF 2.1.0 reports.
+var SARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// sarifRules describes the rules of the error and warning codes with their
+// default level, codes of custom testers get a rule with the code as
+// description.
+var sarifRules = []struct {
+    code        string
+    level       string
+    description string
+}{
+    {importer.CodeReadingSheet, "error", "Worksheet cannot be read"},
+    {importer.CodeNoHeader, "error", "No header found in worksheet"},
+    {importer.CodeHeaderNotAligned, "error", "Headers are not aligned in one row"},
+    {importer.CodeHeaderNotFound, "error", "Header of hazop element not found"},
+    {importer.CodeHeaderMulCoords, "error", "Header of hazop element matched by several cells"},
+    {importer.CodeHeaderCandidates, "warning", "Header cell chosen among several candidates"},
+    {importer.CodeMappingIgnored, "warning", "Header mapping ignored"},
+    {importer.CodeValueInvalid, "error", "Cell value is invalid"},
+    {importer.CodeParsingInteger, "error", "Cell value is not an integer"},
+    {importer.CodeParsingFloat, "error", "Cell value is not a number"},
+    {importer.CodeAmbiguousNumber, "error", "Decimal separator of cell value is ambiguous"},
+    {importer.CodeParsingBoolean, "error", "Cell value is not a boolean"},
+    {importer.CodeParsingDate, "error", "Cell value is not a date"},
+    {importer.CodeAmbiguousDate, "error", "Day and month of cell value are ambiguous"},
+    {importer.CodeParsingEnum, "error", "Cell value is not a value of the element"},
+    {importer.CodeParsingRefList, "error", "Cell value is not a reference list"},
+    {importer.CodeValueOutOfRange, "error", "Cell value length or range out of bounds"},
+}
+
+var sarifLevels = map[importer.Severity]string{
+    importer.SeverityError:   "error",
+    importer.SeverityWarning: "warning",
+}
+
+type sarifLog struct {
+    Schema  string     `json:"$schema"`
+    Version string     `json:"version"`
+    Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+    Tool    sarifTool     `json:"tool"`
+    Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+    Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+    Name    string      `json:"name"`
+    Version string      `json:"version,omitempty"`
+    Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+    Id                   string        `json:"id"`
+    ShortDescription     sarifMessage  `json:"shortDescription"`
+    DefaultConfiguration sarifRuleConf `json:"defaultConfiguration"`
+}
+
+type sarifRuleConf struct {
+    Level string `json:"level"`
+}
+
+type sarifMessage struct {
+    Text string `json:"text"`
+}
+
+type sarifResult struct {
+    RuleId              string            `json:"ruleId"`
+    RuleIndex           int               `json:"ruleIndex"`
+    Level               string            `json:"level"`
+    Message             sarifMessage      `json:"message"`
+    Locations           []sarifLocation   `json:"locations"`
+    PartialFingerprints map[string]string `json:"partialFingerprints"`
+    Properties          sarifProperties   `json:"properties"`
+}
+
+type sarifLocation struct {
+    PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+    LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+    ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+    URI       string `json:"uri"`
+    URIBaseId string `json:"uriBaseId,omitempty"`
+}
+
+// sarifLogicalLocation is the worksheet or the cell of a worksheet, e.g.
+// 'Node 1'!F2.
+type sarifLogicalLocation struct {
+    Name               string `json:"name"`
+    FullyQualifiedName string `json:"fullyQualifiedName"`
+    Kind               string `json:"kind"`
+}
+
+type sarifProperties struct {
+    Worksheet string `json:"worksheet"`
+    Cell      string `json:"cell,omitempty"`
+    Element   string `json:"element,omitempty"`
+    Value     string `json:"value,omitempty"`
+    Expected  string `json:"expected,omitempty"`
+}
+
+// WorkbookPath returns the path of the source workbook relative to
+// SourceRoot, the working directory if empty, with forward slashes. Paths
+// outside of SourceRoot are absolute, without a source workbook the workbook
+// name is returned.
+func (e *Exporter) WorkbookPath() string {
+    if e.File == nil || e.File.Path == "" {
+        return e.Workbook
+    }
+
+    fpath, err := filepath.Abs(e.File.Path)
+    if err != nil {
+        return filepath.ToSlash(filepath.Clean(e.File.Path))
+    }
+
+    root := e.SourceRoot
+    if root == "" {
+        root = "."
+    }
+    if root, err = filepath.Abs(root); err == nil {
+        rel, err := filepath.Rel(root, fpath)
+        if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+            return filepath.ToSlash(rel)
+        }
+    }
+    return filepath.ToSlash(fpath)
+}
+
+// sarifArtifact returns the location of the workbook, relative paths are
+// relative to %SRCROOT% and absolute paths are file URIs.
+func sarifArtifact(fpath string) sarifArtifactLocation {
+    if !filepath.IsAbs(filepath.FromSlash(fpath)) {
+        return sarifArtifactLocation{URI: fpath, URIBaseId: "%SRCROOT%"}
+    }
+    if !strings.HasPrefix(fpath, "/") {
+        fpath = "/" + fpath
+    }
+    return sarifArtifactLocation{URI: (&url.URL{Scheme: "file", Path: fpath}).String()}
+}
+
+// WriteSARIF writes a SARIF 2.1.0 log with a result per error and warning.
+// The rule of a result is the diagnostic code, its location the workbook
+// path and the worksheet or the cell of the worksheet.
+func WriteSARIF(w io.Writer, e *Exporter) error {
+    driver := sarifDriver{Name: e.AppName, Version: e.AppVersion}
+    rules := make(map[string]int, len(sarifRules))
+    for _, r := range sarifRules {
+        rules[r.code] = len(driver.Rules)
+        driver.Rules = append(driver.Rules, sarifRule{
+            Id:                   r.code,
+            ShortDescription:     sarifMessage{Text: r.description},
+            DefaultConfiguration: sarifRuleConf{Level: r.level},
+        })
+    }
+
+    uri := sarifArtifact(e.WorkbookPath())
+
+    results := []sarifResult{}
+    for _, ws := range e.Worksheets {
+        for _, d := range ws.Report.Diagnostics {
+            level, ok := sarifLevels[d.Severity]
+            if !ok {
+                continue
+            }
+
+            index, ok := rules[d.Code]
+            if !ok {
+                index = len(driver.Rules)
+                rules[d.Code] = index
+                driver.Rules = append(driver.Rules, sarifRule{
+                    Id:                   d.Code,
+                    ShortDescription:     sarifMessage{Text: d.Code},
+                    DefaultConfiguration: sarifRuleConf{Level: level},
+                })
+            }
+
+            sheet := quoteSheet(ws.Name)
+            location := sarifLogicalLocation{Name: ws.Name, FullyQualifiedName: sheet, Kind: "module"}
+            if d.Cell != "" {
+                location = sarifLogicalLocation{Name: d.Cell, FullyQualifiedName: sheet + "!" + d.Cell, Kind: "element"}
+            }
+
+            var element string
+            if d.Element != nil {
+                element = ws.Elements[*d.Element].Name
+            }
+
+            results = append(results, sarifResult{
+                RuleId:    d.Code,
+                RuleIndex: index,
+                Level:     level,
+                Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", location.FullyQualifiedName, d.Message)},
+                Locations: []sarifLocation{{
+                    PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: uri},
+                    LogicalLocations: []sarifLogicalLocation{location},
+                }},
+                PartialFingerprints: map[string]string{
+                    "hazopLocation/v1": fmt.Sprintf("%s:%s:%s", location.FullyQualifiedName, element, d.Code),
+                },
+                Properties: sarifProperties{
+                    Worksheet: ws.Name,
+                    Cell:      d.Cell,
+                    Element:   element,
+                    Value:     d.Value,
+                    Expected:  d.Expected,
+                },
+            })
+        }
+    }
+
+    log := sarifLog{
+        Schema:  SARIFSchema,
+        Version: "2.1.0",
+        Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+    }
+
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(log)
+}
@@ -0,0 +1,83 @@
+package exporter
+
+import (
+    "bytes"
+    "encoding/json"
+    "path/filepath"
+    "testing"
+
+    "github.com/dimakdev/HAZOP2RDF2/pkg/importer"
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestWriteSARIF(t *testing.T) {
+    assert := assert.New(t)
+
+    ws := reportWorksheet()
+    ws.Report.Add(importer.Diagnostic{Code: "custom", Severity: importer.SeverityWarning, Cell: "B2", Message: "custom check"})
+    exp := &Exporter{AppName: "HAZOP2RDF2", AppVersion: "1.0.0", Workbook: "Hazop.xlsx", Worksheets: []*importer.Worksheet{ws}}
+
+    var buf bytes.Buffer
+    assert.Empty(WriteSARIF(&buf, exp))
+
+    var log sarifLog
+    assert.Empty(json.Unmarshal(buf.Bytes(), &log))
+    assert.Equal("2.1.0", log.Version)
+    assert.Len(log.Runs, 1)
+
+    run := log.Runs[0]
+    assert.Equal("HAZOP2RDF2", run.Tool.Driver.Name)
+    assert.Len(run.Tool.Driver.Rules, len(sarifRules)+1)
+    assert.Len(run.Results, 4)
+
+    r := run.Results[1]
+    assert.Equal(importer.CodeHeaderNotFound, r.RuleId)
+    assert.Equal(importer.CodeHeaderNotFound, run.Tool.Driver.Rules[r.RuleIndex].Id)
+    assert.Equal("error", r.Level)
+    assert.Equal(sarifArtifactLocation{URI: "Hazop.xlsx", URIBaseId: "%SRCROOT%"}, r.Locations[0].PhysicalLocation.ArtifactLocation)
+    assert.Equal(sarifLogicalLocation{Name: "Node <1>", FullyQualifiedName: "'Node <1>'", Kind: "module"}, r.Locations[0].LogicalLocations[0])
+
+    r = run.Results[2]
+    assert.Equal(importer.CodeParsingInteger, r.RuleId)
+    assert.Equal("'Node <1>'!A3: Error parsing integer `A3`", r.Message.Text)
+    assert.Equal(sarifLogicalLocation{Name: "A3", FullyQualifiedName: "'Node <1>'!A3", Kind: "element"}, r.Locations[0].LogicalLocations[0])
+    assert.Equal(sarifProperties{Worksheet: "Node <1>", Cell: "A3", Element: "Reference", Value: "one"}, r.Properties)
+
+    r = run.Results[3]
+    assert.Equal("custom", run.Tool.Driver.Rules[r.RuleIndex].Id)
+    assert.Equal("warning", r.Level)
+}
+
+func TestWorkbookPath(t *testing.T) {
+    assert := assert.New(t)
+
+    exp := &Exporter{Workbook: "Hazop.xlsx"}
+    assert.Equal("Hazop.xlsx", exp.WorkbookPath())
+
+    exp.File = excelize.NewFile()
+    exp.File.Path = "./hazop/../hazop/Hazop.xlsx"
+    assert.Equal("hazop/Hazop.xlsx", exp.WorkbookPath())
+
+    root := t.TempDir()
+    exp.SourceRoot = root
+    exp.File.Path = filepath.Join(root, "hazop", "Hazop.xlsx")
+    assert.Equal("hazop/Hazop.xlsx", exp.WorkbookPath())
+
+    exp.SourceRoot = filepath.Join(root, "graph")
+    assert.Equal(filepath.ToSlash(exp.File.Path), exp.WorkbookPath())
+}
+
+func TestSARIFArtifact(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal(sarifArtifactLocation{URI: "hazop/Hazop.xlsx", URIBaseId: "%SRCROOT%"}, sarifArtifact("hazop/Hazop.xlsx"))
+    assert.Equal(sarifArtifactLocation{URI: "file:///data/Node%201.xlsx"}, sarifArtifact("/data/Node 1.xlsx"))
+}
+
+func TestQuoteSheet(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal("'Node 1'", quoteSheet("Node 1"))
+    assert.Equal("'Bob''s node'", quoteSheet("Bob's node"))
+}
@@ -0,0 +1,191 @@
+package importer
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/xuri/excelize/v2"
+)
+
+var (
+    ErrParsingDate   = errors.New("Error parsing date")
+    ErrDateBound     = errors.New("Error parsing date bound")
+    ErrAmbiguousDate = errors.New("Error ambiguous date")
+)
+
+// dateLayouts are the accepted layouts of date cells, including the
+// renderings of the built-in Excel date formats by excelize.
+var dateLayouts = []string{
+    "2006-01-02",
+    "02.01.2006",
+    "2.1.2006",
+    "01-02-06",
+    "2-Jan-06",
+    "2-Jan-2006",
+    "2 January 2006",
+    "January 2, 2006",
+}
+
+// slashLayouts are the day-first and month-first readings of dates with
+// slashes, e.g. 03/04/2021.
+var slashLayouts = [][2]string{
+    {"2/1/2006", "1/2/2006"},
+    {"2/1/06", "1/2/06"},
+}
+
+// dateTimeLayouts are the accepted layouts of date cells with a time,
+// `1/2/06 15:04` is the rendering of the Excel format m/d/yy h:mm.
+var dateTimeLayouts = []string{
+    "2006-01-02T15:04:05Z07:00",
+    "2006-01-02T15:04:05",
+    "2006-01-02 15:04:05",
+    "2006-01-02 15:04",
+    "02.01.2006 15:04:05",
+    "02.01.2006 15:04",
+    "1/2/06 15:04",
+}
+
+// minSerialYear and maxSerialYear bound the years of Excel serial numbers
+// read as dates, other numbers like 2021 are not dates.
+const (
+    minSerialYear = 1950
+    maxSerialYear = 2100
+)
+
+// utc is the location of date times parsed with the zone Z, zone-less date
+// times are in time.UTC.
+var utc = time.FixedZone("UTC", 0)
+
+// testDate parses dates and date times from Excel serial numbers and the
+// textual layouts of the element or the default layouts. Dates are bounded
+// by min_date and max_date in a default layout, nil bounds are open.
+// Serial numbers are read in the 1904 date system of the workbook if set.
+type testDate struct {
+    datetime bool
+    date1904 bool
+    layouts  []string
+    minDate  *time.Time
+    maxDate  *time.Time
+}
+
+func newTestDate(e HazopElement) (Tester, error) {
+    t := testDate{datetime: e.Type() == DateTimeType, layouts: e.Layouts}
+
+    for _, b := range []struct {
+        value string
+        date  **time.Time
+    }{
+        {e.MinDate, &t.minDate},
+        {e.MaxDate, &t.maxDate},
+    } {
+        if b.value == "" {
+            continue
+        }
+        v, err := testDate{datetime: t.datetime}.TestCellType(b.value)
+        if err != nil {
+            return nil, fmt.Errorf("%w `%d:%s` `%s`", ErrDateBound, e.Id, e.Name, b.value)
+        }
+        d := v.(time.Time)
+        *b.date = &d
+    }
+
+    return t, nil
+}
+
+// TestCellType parses a serial number like 44259.5 between minSerialYear and
+// maxSerialYear or a textual date, dates of date elements are truncated to
+// the day. Date times parsed with a zone keep their offset.
+func (c testDate) TestCellType(value string) (interface{}, error) {
+    value = strings.TrimSpace(value)
+
+    if v, err := strconv.ParseFloat(value, 64); err == nil {
+        d, err := excelize.ExcelDateToTime(v, c.date1904)
+        if err == nil && d.Year() >= minSerialYear && d.Year() <= maxSerialYear {
+            return c.truncate(d), nil
+        }
+    }
+
+    layouts := c.layouts
+    if len(layouts) == 0 {
+        layouts = append(append([]string(nil), dateLayouts...), dateTimeLayouts...)
+    }
+
+    for _, layout := range layouts {
+        if d, err := time.Parse(layout, value); err == nil {
+            if d.Location() == time.UTC && zoned(layout) {
+                d = d.In(utc)
+            }
+            return c.truncate(d), nil
+        }
+    }
+
+    if len(c.layouts) == 0 {
+        d, err := parseSlashDate(value)
+        if err != nil {
+            return nil, err
+        }
+        return c.truncate(d), nil
+    }
+    return nil, ErrParsingDate
+}
+
+// zoned reports whether the layout has a time zone.
+func zoned(layout string) bool {
+    for _, z := range []string{"Z07", "-07", "MST"} {
+        if strings.Contains(layout, z) {
+            return true
+        }
+    }
+    return false
+}
+
+// parseSlashDate parses a date with slashes by its day-first and month-first
+// readings. The date is ambiguous if both readings are valid and differ.
+func parseSlashDate(value string) (time.Time, error) {
+    for _, l := range slashLayouts {
+        dayFirst, derr := time.Parse(l[0], value)
+        monthFirst, merr := time.Parse(l[1], value)
+        switch {
+        case derr == nil && merr == nil && !dayFirst.Equal(monthFirst):
+            return time.Time{}, ErrAmbiguousDate
+        case derr == nil:
+            return dayFirst, nil
+        case merr == nil:
+            return monthFirst, nil
+        }
+    }
+    return time.Time{}, ErrParsingDate
+}
+
+// truncate drops the time of date elements and rounds date times to the
+// second, serial numbers carry float noise.
+func (c testDate) truncate(d time.Time) time.Time {
+    if c.datetime {
+        return d.Round(time.Second)
+    }
+    return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// TestCellLength verifies the date against min_date and max_date.
+func (c testDate) TestCellLength(value interface{}, min, max int) error {
+    d := value.(time.Time)
+    if (c.minDate != nil && d.Before(*c.minDate)) || (c.maxDate != nil && d.After(*c.maxDate)) {
+        return fmt.Errorf("%w %s-%s", ErrValueOutOfRange, c.formatBound(c.minDate), c.formatBound(c.maxDate))
+    }
+    return nil
+}
+
+// formatBound formats a date bound, open bounds are empty.
+func (c testDate) formatBound(d *time.Time) string {
+    switch {
+    case d == nil:
+        return ""
+    case c.datetime:
+        return d.Format("2006-01-02T15:04:05")
+    default:
+        return d.Format("2006-01-02")
+    }
+}
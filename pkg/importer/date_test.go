@@ -0,0 +1,135 @@
+package importer
+
+import (
+    "context"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestTestDate(tt *testing.T) {
+    day := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+    noon := time.Date(2021, 3, 4, 13, 45, 0, 0, time.UTC)
+    for _, tc := range []struct {
+        name    string
+        element HazopElement
+        value   string
+        want    time.Time
+        err     string
+    }{
+        {name: "iso", element: HazopElement{DataType: "date"}, value: "2021-03-04", want: day},
+        {name: "german", element: HazopElement{DataType: "date"}, value: "04.03.2021", want: day},
+        {name: "short german", element: HazopElement{DataType: "date"}, value: " 4.3.2021 ", want: day},
+        {name: "ambiguous slashes", element: HazopElement{DataType: "date"}, value: "03/04/2021", err: ErrAmbiguousDate.Error()},
+        {name: "ambiguous short slashes", element: HazopElement{DataType: "date"}, value: "3/4/21", err: ErrAmbiguousDate.Error()},
+        {name: "us", element: HazopElement{DataType: "date"}, value: "03/14/2021", want: day.AddDate(0, 0, 10)},
+        {name: "british", element: HazopElement{DataType: "date"}, value: "14/03/2021", want: day.AddDate(0, 0, 10)},
+        {name: "same day and month", element: HazopElement{DataType: "date"}, value: "3/3/2021", want: day.AddDate(0, 0, -1)},
+        {name: "us layout", element: HazopElement{DataType: "date", Layouts: []string{"01/02/2006"}}, value: "03/04/2021", want: day},
+        {name: "excel mm-dd-yy", element: HazopElement{DataType: "date"}, value: "03-04-21", want: day},
+        {name: "excel d-mmm-yy", element: HazopElement{DataType: "date"}, value: "4-Mar-21", want: day},
+        {name: "excel m/d/yy h:mm", element: HazopElement{DataType: "date"}, value: "3/4/21 13:45", want: day},
+        {name: "serial", element: HazopElement{DataType: "date"}, value: "44259", want: day},
+        {name: "serial with time", element: HazopElement{DataType: "date"}, value: "44259.572916666664", want: day},
+        {name: "datetime serial", element: HazopElement{DataType: "datetime"}, value: "44259.572916666664", want: noon},
+        {name: "datetime iso", element: HazopElement{DataType: "datetime"}, value: "2021-03-04T13:45:00", want: noon},
+        {name: "datetime excel", element: HazopElement{DataType: "datetime"}, value: "3/4/21 13:45", want: noon},
+        {name: "datetime date only", element: HazopElement{DataType: "datetime"}, value: "2021-03-04", want: day},
+        {name: "layout", element: HazopElement{DataType: "date", Layouts: []string{"02/01/2006"}}, value: "04/03/2021", want: day},
+        {name: "layout replaces defaults", element: HazopElement{DataType: "date", Layouts: []string{"02/01/2006"}}, value: "2021-03-04", err: ErrParsingDate.Error()},
+        {name: "text", element: HazopElement{DataType: "date"}, value: "next week", err: ErrParsingDate.Error()},
+        {name: "time only", element: HazopElement{DataType: "date"}, value: "13:45", err: ErrParsingDate.Error()},
+        {name: "negative serial", element: HazopElement{DataType: "date"}, value: "-1", err: ErrParsingDate.Error()},
+        {name: "year is no serial", element: HazopElement{DataType: "date"}, value: "2021", err: ErrParsingDate.Error()},
+        {name: "serial after range", element: HazopElement{DataType: "date"}, value: "100000", err: ErrParsingDate.Error()},
+        {name: "numeric layout", element: HazopElement{DataType: "date", Layouts: []string{"20060102"}}, value: "20210304", want: day},
+        {name: "datetime offset", element: HazopElement{DataType: "datetime"}, value: "2021-03-04T19:30:00+05:45", want: noon.In(time.FixedZone("", 20700))},
+        {name: "datetime zone z", element: HazopElement{DataType: "datetime"}, value: "2021-03-04T13:45:00Z", want: noon.In(utc)},
+        {name: "in range", element: HazopElement{DataType: "date", MinDate: "2021-01-01", MaxDate: "2021-12-31"}, value: "04.03.2021", want: day},
+        {name: "on min", element: HazopElement{DataType: "date", MinDate: "2021-03-04"}, value: "2021-03-04", want: day},
+        {name: "before min", element: HazopElement{DataType: "date", MinDate: "2021-06-01", MaxDate: "2021-12-31"}, value: "2021-03-04", err: ErrValueOutOfRange.Error() + " 2021-06-01-2021-12-31"},
+        {name: "after max", element: HazopElement{DataType: "datetime", MaxDate: "2021-03-04 12:00"}, value: "44259.572916666664", err: ErrValueOutOfRange.Error() + " -2021-03-04T12:00:00"},
+    } {
+        t, err := NewTester(tc.element)
+        assert.Empty(tt, err, tc.name)
+
+        v, err := t.TestCellType(tc.value)
+        if err == nil {
+            err = t.TestCellLength(v, tc.element.MinLen, tc.element.MaxLen)
+        }
+
+        if tc.err != "" {
+            assert.EqualError(tt, err, tc.err, tc.name)
+            continue
+        }
+        assert.Empty(tt, err, tc.name)
+        assert.Equal(tt, tc.want, v, tc.name)
+    }
+}
+
+func TestDate1904(t *testing.T) {
+    assert := assert.New(t)
+
+    v, err := testDate{date1904: true}.TestCellType("42797")
+    assert.Empty(err)
+    assert.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), v)
+
+    f := excelize.NewFile()
+    f.WorkBook.WorkbookPr.Date1904 = true
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Action", "Due date"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Check relief sizing", 42797})
+
+    fpath := filepath.Join(t.TempDir(), "dates1904.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbookContext(context.Background(), fpath, Options{Hazop: &HazopElements{Elements: []HazopElement{
+        {Id: 10, Name: "Action", Regex: "^(?i)(action)$", MinLen: 1, MaxLen: 160},
+        {Id: 15, Name: "DueDate", Regex: "^(?i)(due)", DataType: "date"},
+    }}})
+    assert.Empty(err)
+    assert.True(wb.date1904)
+    assert.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), wb.Worksheets[0].Graph[0]["DueDate"])
+}
+
+func TestDateBound(t *testing.T) {
+    assert := assert.New(t)
+
+    tester, err := NewTester(HazopElement{Id: 15, Name: "DueDate", DataType: "date", MinDate: "someday"})
+    assert.EqualError(err, ErrDateBound.Error()+" `15:DueDate` `someday`")
+    assert.Empty(tester)
+}
+
+func TestImportDates(t *testing.T) {
+    assert := assert.New(t)
+
+    elements := Hazop.Elements
+    defer func() { Hazop.Elements = elements }()
+    Hazop.Elements = []HazopElement{
+        {Id: 10, Name: "Action", Regex: "^(?i)(action)$", MinLen: 1, MaxLen: 160},
+        {Id: 15, Name: "DueDate", Regex: "^(?i)(due)", DataType: "date", MinDate: "2020-01-01"},
+        {Id: 16, Name: "ClosedAt", Regex: "^(?i)(closed)", DataType: "datetime"},
+    }
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Action", "Due date", "Closed"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Check relief sizing", time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 4, 13, 45, 0, 0, time.UTC)})
+    f.SetSheetRow("Sheet1", "A3", &[]interface{}{"Fit alarm", "2019-12-31", "04.03.2021 13:45"})
+    style, _ := f.NewStyle(&excelize.Style{NumFmt: 14})
+    f.SetCellStyle("Sheet1", "B2", "B2", style)
+
+    fpath := filepath.Join(t.TempDir(), "dates.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws := wb.Worksheets[0]
+    assert.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), ws.Graph[0]["DueDate"])
+    assert.Equal(time.Date(2021, 3, 4, 13, 45, 0, 0, time.UTC), ws.Graph[0]["ClosedAt"])
+    assert.NotContains(ws.Graph[1], "DueDate")
+    assert.Equal(time.Date(2021, 3, 4, 13, 45, 0, 0, time.UTC), ws.Graph[1]["ClosedAt"])
+    assert.Contains(ws.Report.Errors(), ErrValueOutOfRange.Error()+" 2020-01-01- `B3`")
+}
@@ -0,0 +1,221 @@
+package importer
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// Severity of a diagnostic.
+type Severity string
+
+const (
+    SeverityError   Severity = "error"
+    SeverityWarning Severity = "warning"
+    SeverityInfo    Severity = "info"
+)
+
+// Diagnostic codes, they are stable and identify the kind of a diagnostic.
+const (
+    CodeReadingSheet     = "reading-sheet"
+    CodeNoHeader         = "no-header"
+    CodeHeaderNotAligned = "header-not-aligned"
+    CodeHeaderNotFound   = "header-not-found"
+    CodeHeaderMulCoords  = "header-multiple-coordinates"
+    CodeHeaderCandidates = "header-candidates"
+    CodeMappingIgnored   = "mapping-ignored"
+    CodeHeaderAligned    = "header-aligned"
+    CodeHeaderFound      = "header-found"
+    CodeHeaderMapped     = "header-mapped"
+    CodeSheetOverride    = "sheet-override"
+    CodeValueValid       = "value-valid"
+    CodeValueInherited   = "value-inherited"
+    CodeValueInvalid     = "value-invalid"
+    CodeParsingInteger   = "parsing-integer"
+    CodeParsingFloat     = "parsing-float"
+    CodeAmbiguousNumber  = "ambiguous-number"
+    CodeParsingBoolean   = "parsing-boolean"
+    CodeParsingDate      = "parsing-date"
+    CodeAmbiguousDate    = "ambiguous-date"
+    CodeParsingEnum      = "parsing-enum"
+    CodeParsingRefList   = "parsing-reference-list"
+    CodeValueOutOfRange  = "value-out-of-range"
+)
+
+// valueCodes maps the sentinel errors of testers to their codes.
+var valueCodes = []struct {
+    err  error
+    code string
+}{
+    {ErrParsingInteger, CodeParsingInteger},
+    {ErrParsingFloat, CodeParsingFloat},
+    {ErrAmbiguousNumber, CodeAmbiguousNumber},
+    {ErrParsingBoolean, CodeParsingBoolean},
+    {ErrParsingDate, CodeParsingDate},
+    {ErrAmbiguousDate, CodeAmbiguousDate},
+    {ErrParsingEnum, CodeParsingEnum},
+    {ErrParsingRefList, CodeParsingRefList},
+    {ErrValueOutOfRange, CodeValueOutOfRange},
+}
+
+// valueCode returns the code of a tester error, errors of custom testers
+// are invalid values.
+func valueCode(err error) string {
+    for _, c := range valueCodes {
+        if errors.Is(err, c.err) {
+            return c.code
+        }
+    }
+    return CodeValueInvalid
+}
+
+// IsCellCheck reports whether the code is the verification of a cell value,
+// valid or not.
+func IsCellCheck(code string) bool {
+    if code == CodeValueValid || code == CodeValueInvalid {
+        return true
+    }
+    for _, c := range valueCodes {
+        if code == c.code {
+            return true
+        }
+    }
+    return false
+}
+
+// Diagnostic is a finding of the import. Element is the hazop element id,
+// Value the observed cell value and Expected the constraint it is verified
+// against. Message is the text shown in reports.
+type Diagnostic struct {
+    Code      string   `json:"code"`
+    Severity  Severity `json:"severity"`
+    Worksheet string   `json:"worksheet,omitempty"`
+    Cell      string   `json:"cell,omitempty"`
+    Element   *int     `json:"element,omitempty"`
+    Value     string   `json:"value,omitempty"`
+    Expected  string   `json:"expected,omitempty"`
+    Message   string   `json:"message"`
+    Err       error    `json:"-"`
+}
+
+// Error returns the message, a diagnostic unwraps to its sentinel error.
+func (d Diagnostic) Error() string {
+    return d.Message
+}
+
+func (d Diagnostic) Unwrap() error {
+    return d.Err
+}
+
+// elementId returns a reference to the id of the element.
+func elementId(e HazopElement) *int {
+    id := e.Id
+    return &id
+}
+
+// Report collects the diagnostics of a worksheet in order.
+type Report struct {
+    Diagnostics []Diagnostic
+}
+
+// Add appends the diagnostic.
+func (r *Report) Add(d Diagnostic) {
+    r.Diagnostics = append(r.Diagnostics, d)
+}
+
+// Filter returns the diagnostics f accepts.
+func (r *Report) Filter(f func(Diagnostic) bool) []Diagnostic {
+    var res []Diagnostic
+    for _, d := range r.Diagnostics {
+        if f(d) {
+            res = append(res, d)
+        }
+    }
+    return res
+}
+
+// Severity returns the diagnostics of the severity.
+func (r *Report) Severity(s Severity) []Diagnostic {
+    return r.Filter(func(d Diagnostic) bool { return d.Severity == s })
+}
+
+// Code returns the diagnostics of the code.
+func (r *Report) Code(code string) []Diagnostic {
+    return r.Filter(func(d Diagnostic) bool { return d.Code == code })
+}
+
+// Cell returns the diagnostics of the cell.
+func (r *Report) Cell(cell string) []Diagnostic {
+    return r.Filter(func(d Diagnostic) bool { return d.Cell == cell })
+}
+
+// Count returns the number of diagnostics of the severity.
+func (r *Report) Count(s Severity) int {
+    return len(r.Severity(s))
+}
+
+// CountByCode returns the number of diagnostics per code.
+func (r *Report) CountByCode() map[string]int {
+    counts := make(map[string]int)
+    for _, d := range r.Diagnostics {
+        counts[d.Code] += 1
+    }
+    return counts
+}
+
+// Messages returns the messages of the severity.
+func (r *Report) Messages(s Severity) []string {
+    var msgs []string
+    for _, d := range r.Severity(s) {
+        msgs = append(msgs, d.Message)
+    }
+    return msgs
+}
+
+// Errors returns the error messages.
+func (r *Report) Errors() []string {
+    return r.Messages(SeverityError)
+}
+
+// Warnings returns the warning messages.
+func (r *Report) Warnings() []string {
+    return r.Messages(SeverityWarning)
+}
+
+// Info returns the info messages.
+func (r *Report) Info() []string {
+    return r.Messages(SeverityInfo)
+}
+
+// diagnose adds the diagnostic of the worksheet to its report.
+func (ws *Worksheet) diagnose(d Diagnostic) {
+    d.Worksheet = ws.Name
+    ws.Report.Add(d)
+}
+
+// Constraint describes what the values of the element are verified against,
+// e.g. `integer len 1-10 value 1-320`.
+func (e HazopElement) Constraint() string {
+    parts := []string{e.Type()}
+    switch e.Type() {
+    case DateType, DateTimeType, BooleanType, EnumType:
+    default:
+        parts = append(parts, fmt.Sprintf("len %d-%d", e.MinLen, e.MaxLen))
+    }
+    if e.MinValue != nil || e.MaxValue != nil {
+        parts = append(parts, fmt.Sprintf("value %s-%s", formatBound(e.MinValue), formatBound(e.MaxValue)))
+    }
+    if e.MinDate != "" || e.MaxDate != "" {
+        parts = append(parts, fmt.Sprintf("date %s-%s", e.MinDate, e.MaxDate))
+    }
+    if e.Vocabulary != "" {
+        parts = append(parts, "vocabulary "+e.Vocabulary)
+    }
+    if len(e.Values) > 0 {
+        parts = append(parts, fmt.Sprintf("values %v", e.Values))
+    }
+    if e.Split != "" {
+        parts = append(parts, "split "+e.Split)
+    }
+    return strings.Join(parts, " ")
+}
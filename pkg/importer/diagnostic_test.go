@@ -0,0 +1,105 @@
+package importer
+
+import (
+    "errors"
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestValueCode(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal(CodeParsingInteger, valueCode(ErrParsingInteger))
+    tester, _ := NewTester(HazopElement{DataType: "integer", MaxLen: 3})
+    _, err := tester.TestCellType("abc")
+    assert.Equal(CodeParsingInteger, valueCode(err))
+    assert.Equal(CodeAmbiguousNumber, valueCode(ErrAmbiguousNumber))
+    assert.Equal(CodeAmbiguousDate, valueCode(ErrAmbiguousDate))
+    assert.Equal(CodeValueInvalid, valueCode(errors.New("custom")))
+
+    assert.True(IsCellCheck(CodeValueValid))
+    assert.True(IsCellCheck(CodeValueOutOfRange))
+    assert.False(IsCellCheck(CodeValueInherited))
+    assert.False(IsCellCheck(CodeHeaderNotFound))
+}
+
+func TestReport(t *testing.T) {
+    assert := assert.New(t)
+
+    r := &Report{}
+    r.Add(Diagnostic{Code: CodeHeaderFound, Severity: SeverityInfo, Cell: "A1", Message: "found"})
+    r.Add(Diagnostic{Code: CodeParsingInteger, Severity: SeverityError, Cell: "A2", Message: "integer", Err: ErrParsingInteger})
+    r.Add(Diagnostic{Code: CodeParsingInteger, Severity: SeverityError, Cell: "A3", Message: "integer", Err: ErrParsingInteger})
+    r.Add(Diagnostic{Code: CodeHeaderCandidates, Severity: SeverityWarning, Cell: "B1", Message: "candidates"})
+
+    assert.Equal(2, r.Count(SeverityError))
+    assert.Equal([]string{"candidates"}, r.Warnings())
+    assert.Equal([]string{"found"}, r.Info())
+    assert.Len(r.Code(CodeParsingInteger), 2)
+    assert.Len(r.Cell("A2"), 1)
+    assert.Equal(map[string]int{CodeHeaderFound: 1, CodeParsingInteger: 2, CodeHeaderCandidates: 1}, r.CountByCode())
+    assert.True(errors.Is(r.Diagnostics[1], ErrParsingInteger))
+    assert.Equal("integer", r.Diagnostics[1].Error())
+}
+
+func TestConstraint(t *testing.T) {
+    assert := assert.New(t)
+
+    one, five := 1.0, 5.0
+    assert.Equal("integer len 1-3 value 1-5", HazopElement{DataType: "integer", MinLen: 1, MaxLen: 3, MinValue: &one, MaxValue: &five}.Constraint())
+    assert.Equal("float len 0-8 value -5", HazopElement{DataType: "float", MaxLen: 8, MaxValue: &five}.Constraint())
+    assert.Equal("date date 2020-01-01-", HazopElement{DataType: "date", MinDate: "2020-01-01"}.Constraint())
+    assert.Equal("enum vocabulary guidewords", HazopElement{DataType: "enum", Vocabulary: "guidewords"}.Constraint())
+    assert.Equal("string len 1-30 split list", HazopElement{MinLen: 1, MaxLen: 30, Split: "list"}.Constraint())
+}
+
+func TestImportDiagnostics(t *testing.T) {
+    assert := assert.New(t)
+
+    elements := Hazop.Elements
+    defer func() { Hazop.Elements = elements }()
+    maxValue := 320.0
+    Hazop.Elements = []HazopElement{
+        {Id: 2, Name: "Reference", Regex: "^(?i)(ref)", DataType: "integer", MinLen: 1, MaxLen: 10, MaxValue: &maxValue},
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", MinLen: 1, MaxLen: 80, FillDown: true},
+    }
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Ref", "Deviation"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"1", "More flow"})
+    f.SetSheetRow("Sheet1", "A3", &[]interface{}{"one", ""})
+    f.SetSheetRow("Sheet1", "A4", &[]interface{}{"400", "Less flow"})
+
+    fpath := filepath.Join(t.TempDir(), "diagnostics.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws := wb.Worksheets[0]
+    assert.Equal(2, ws.Report.Count(SeverityError))
+
+    d := ws.Report.Cell("A3")
+    assert.Len(d, 1)
+    assert.Equal(CodeParsingInteger, d[0].Code)
+    assert.Equal("Sheet1", d[0].Worksheet)
+    assert.Equal(2, *d[0].Element)
+    assert.Equal("one", d[0].Value)
+    assert.Equal("integer len 1-10 value -320", d[0].Expected)
+    assert.True(errors.Is(d[0], ErrParsingInteger))
+
+    d = ws.Report.Code(CodeValueOutOfRange)
+    assert.Len(d, 1)
+    assert.Equal("A4", d[0].Cell)
+    assert.True(errors.Is(d[0].Err, ErrValueOutOfRange))
+
+    d = ws.Report.Code(CodeValueInherited)
+    assert.Len(d, 1)
+    assert.Equal("B3", d[0].Cell)
+    assert.Equal("More flow", d[0].Value)
+
+    assert.Equal(2, ws.Report.CountByCode()[CodeHeaderFound])
+}
@@ -0,0 +1,443 @@
+package importer
+
+import (
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+
+    "github.com/xuri/excelize/v2"
+)
+
+// maxHeaderRows is the maximum number of rows of a header block.
+const maxHeaderRows = 4
+
+// headerRow is a row read before the header block was found, coords are its
+// own header matches.
+type headerRow struct {
+    y      int
+    cols   []string
+    coords map[int][]string
+}
+
+// headerBlock is a candidate header of the rows top to bottom. A column is
+// identified by the path of its header cells, coords are the leaf cells
+// matched by an element and headers the chosen cells, k (key) - hazop
+// element id.
+type headerBlock struct {
+    top     int
+    bottom  int
+    coords  map[int][]string
+    headers map[int]string
+    paths   map[string]string
+    score   int
+}
+
+// headerCandidate is a leaf cell matched by an element.
+type headerCandidate struct {
+    id      int
+    cell    string
+    x       int
+    y       int
+    aligned bool
+    exact   bool
+}
+
+// headerSearch buffers the last rows above the header block.
+type headerSearch struct {
+    rows    []headerRow
+    coords  map[int][]string
+    pending *headerBlock
+    // done is set when a forced header row matches less than two elements
+    done bool
+}
+
+// newHeaderBlock matches the element regexes against the leaf label and the
+// path of every column of rows. Cells of a range over several columns belong
+// to its first column, a range over all columns is a title and not part of
+// the paths.
+func (wb *Workbook) newHeaderBlock(ws *Worksheet, rows []headerRow, m *merges) *headerBlock {
+    hb := &headerBlock{
+        top:     rows[0].y,
+        bottom:  rows[len(rows)-1].y,
+        coords:  make(map[int][]string),
+        headers: make(map[int]string),
+        paths:   make(map[string]string),
+    }
+
+    width := 0
+    for _, r := range rows {
+        if len(r.cols) > width {
+            width = len(r.cols)
+        }
+    }
+
+    // columns whose leaf cell reaches the bottom row are aligned
+    columns := make(map[int][]cellValue, width)
+    aligned := make(map[int]bool, width)
+    for x := 1; x <= width; x++ {
+        var parts []cellValue
+        var leaf cellValue
+        var bottom string
+        for _, r := range rows {
+            cname, err := excelize.CoordinatesToCellName(x, r.y)
+            if err != nil {
+                continue
+            }
+
+            cv, ok := m.cell(cname)
+            if !ok {
+                cv = cellValue{Cell: cname}
+                if x <= len(r.cols) {
+                    cv.Value = r.cols[x-1]
+                }
+            }
+
+            if r.y == hb.bottom {
+                bottom = cv.Cell
+            }
+
+            if cv.Value == "" || cv.Cell == leaf.Cell {
+                continue
+            }
+            parts = append(parts, cv)
+            leaf = cv
+        }
+
+        if len(parts) == 0 {
+            continue
+        }
+
+        lx, _, err := excelize.CellNameToCoordinates(leaf.Cell)
+        if err != nil || lx != x {
+            continue
+        }
+        columns[x] = parts
+        aligned[x] = bottom == leaf.Cell
+    }
+
+    var candidates []headerCandidate
+
+    for x := 1; x <= width; x++ {
+        parts, ok := columns[x]
+        if !ok {
+            continue
+        }
+
+        var labels []string
+        for _, cv := range parts {
+            if len(columns) > 1 && m.widths[cv.Cell] >= len(columns) {
+                continue
+            }
+            labels = append(labels, cv.Value)
+        }
+
+        leaf := parts[len(parts)-1]
+        _, y, _ := excelize.CellNameToCoordinates(leaf.Cell)
+        path := strings.Join(labels, " ")
+        hb.paths[leaf.Cell] = path
+        for _, k := range ws.ElementIds() {
+            re := ws.regexps[k]
+            if !re.MatchString(leaf.Value) && !re.MatchString(path) {
+                continue
+            }
+
+            hb.coords[k] = append(hb.coords[k], leaf.Cell)
+            candidates = append(candidates, headerCandidate{
+                id:      k,
+                cell:    leaf.Cell,
+                x:       x,
+                y:       y,
+                aligned: aligned[x],
+                exact:   matchesExactly(re, leaf.Value) || matchesExactly(re, path),
+            })
+        }
+    }
+
+    wb.chooseHazopHeaders(ws, hb, candidates)
+
+    return hb
+}
+
+// chooseHazopHeaders assigns every element at most one cell and every cell at
+// most one element. Candidates aligned with the bottom row win over others,
+// then exact over partial matches, the topmost row, the element priority and
+// the leftmost column.
+func (wb *Workbook) chooseHazopHeaders(ws *Worksheet, hb *headerBlock, candidates []headerCandidate) {
+    sort.SliceStable(candidates, func(i, j int) bool {
+        a, b := candidates[i], candidates[j]
+        switch {
+        case a.aligned != b.aligned:
+            return a.aligned
+        case a.exact != b.exact:
+            return a.exact
+        case a.y != b.y:
+            return a.y < b.y
+        }
+
+        pa := ws.Elements[a.id].Priority
+        pb := ws.Elements[b.id].Priority
+        switch {
+        case pa != pb:
+            return pa > pb
+        case a.x != b.x:
+            return a.x < b.x
+        default:
+            return a.id < b.id
+        }
+    })
+
+    claimed := make(map[string]bool, len(candidates))
+    for _, c := range candidates {
+        if _, ok := hb.headers[c.id]; ok || claimed[c.cell] {
+            continue
+        }
+        hb.headers[c.id] = c.cell
+        claimed[c.cell] = true
+    }
+    hb.score = len(hb.headers)
+}
+
+// matchesExactly reports whether the regex matches the whole label.
+func matchesExactly(re *regexp.Regexp, label string) bool {
+    loc := re.FindStringIndex(label)
+    return loc != nil && loc[0] == 0 && loc[1] == len(label)
+}
+
+// searchHazopHeaders adds row y to the search. Rows joined by merged ranges
+// form a header block, the block is found if at least two elements match
+// a column of their own. A range over several rows always joins its rows, a
+// range over several columns may group the labels of the next row, that row
+// only joins the block if more elements match. A header row set by an
+// override ends the search, it is accepted if at least two elements match,
+// otherwise only its matches are reported. The found block and the rows read
+// below it are returned.
+func (wb *Workbook) searchHazopHeaders(ws *Worksheet, hs *headerSearch, m *merges, y int, cols []string) (*headerBlock, []headerRow) {
+    row := headerRow{y: y, cols: cols}
+    row.coords = wb.newHeaderBlock(ws, []headerRow{row}, m).coords
+
+    hs.rows = append(hs.rows, row)
+    if len(hs.rows) > maxHeaderRows {
+        hs.drop(hs.rows[0])
+        hs.rows = hs.rows[1:]
+    }
+
+    top := y
+    for top > hs.rows[0].y && (m.spans[top-1] || m.groups[top-1]) {
+        top--
+    }
+    hb := wb.newHeaderBlock(ws, hs.rows[len(hs.rows)-(y-top+1):], m)
+
+    if ws.forcedHeaderRow > 0 {
+        if y < ws.forcedHeaderRow {
+            return nil, nil
+        }
+        if hb.score < 2 {
+            hs.done = true
+            hs.rows = nil
+            hs.coords = make(map[int][]string, len(hb.headers))
+            for k, c := range hb.headers {
+                hs.coords[k] = []string{c}
+            }
+            return nil, nil
+        }
+        hs.pending = hb
+        return hs.accept()
+    }
+
+    switch {
+    case hs.pending != nil && hs.pending.bottom == y-1 && m.spans[y-1]:
+        hs.pending = nil
+        if hb.score >= 2 {
+            hs.pending = hb
+        }
+    case hs.pending != nil && hb.score > hs.pending.score:
+        hs.pending = hb
+    case hs.pending == nil && hb.score >= 2:
+        hs.pending = hb
+    }
+
+    if hs.pending == nil {
+        return nil, nil
+    }
+
+    if (m.spans[y] || m.groups[y]) && y-hs.pending.top+1 < maxHeaderRows {
+        return nil, nil
+    }
+
+    return hs.accept()
+}
+
+// accept returns the pending block and the rows below it, matches of the
+// rows above it are kept.
+func (hs *headerSearch) accept() (*headerBlock, []headerRow) {
+    hb := hs.pending
+    var below []headerRow
+    for _, r := range hs.rows {
+        switch {
+        case r.y < hb.top:
+            hs.drop(r)
+        case r.y > hb.bottom:
+            below = append(below, r)
+        }
+    }
+    hs.rows = nil
+    hs.pending = nil
+
+    return hb, below
+}
+
+func (hs *headerSearch) drop(r headerRow) {
+    for k, c := range r.coords {
+        hs.coords[k] = append(hs.coords[k], c...)
+    }
+}
+
+// setHazopHeaders sets the chosen and mapped headers of the found block,
+// rejected candidates are reported as warnings. Elements matched only above
+// the block are not aligned.
+func (wb *Workbook) setHazopHeaders(ws *Worksheet, hb *headerBlock, above map[int][]string) {
+    mapped := wb.mapHazopHeaders(ws, hb)
+
+    ws.HeaderCells = hb.paths
+    ws.Headers = make(map[int]string, hb.score)
+    ws.HeaderPaths = make(map[int]string, hb.score)
+    ws.HeaderX = make(map[int]int, hb.score)
+    ws.HeaderY = make(map[int]int, hb.score)
+    for _, k := range ws.ElementIds() {
+        e := ws.Elements[k]
+        c := hb.coords[k]
+        h, ok := hb.headers[k]
+
+        var rejected []string
+        for _, cell := range c {
+            if cell != h {
+                rejected = append(rejected, cell)
+            }
+        }
+
+        if len(rejected) > 0 {
+            chosen := "none"
+            if ok {
+                chosen = h
+            }
+            ws.diagnose(Diagnostic{
+                Code:     CodeHeaderCandidates,
+                Severity: SeverityWarning,
+                Cell:     h,
+                Element:  elementId(e),
+                Value:    hb.paths[h],
+                Message:  fmt.Sprintf("%s `%d:%s` chosen %s rejected %v", WarnHeaderCandidates, e.Id, e.Name, chosen, rejected),
+            })
+        }
+
+        switch {
+        case ok:
+            x, y, _ := excelize.CellNameToCoordinates(h)
+            ws.Headers[e.Id] = h
+            ws.HeaderPaths[e.Id] = hb.paths[h]
+            ws.HeaderX[e.Id] = x
+            ws.HeaderY[e.Id] = y
+
+            code, info := CodeHeaderFound, InfoHeaderFound
+            if mapped[k] {
+                code, info = CodeHeaderMapped, InfoHeaderMapped
+            }
+            ws.diagnose(Diagnostic{
+                Code:     code,
+                Severity: SeverityInfo,
+                Cell:     h,
+                Element:  elementId(e),
+                Value:    hb.paths[h],
+                Message:  fmt.Sprintf("%s `%d:%s` %v", info, e.Id, e.Name, []string{h}),
+            })
+        case len(c) > 0:
+        case len(above[k]) > 0:
+            ws.diagnose(Diagnostic{
+                Code:     CodeHeaderNotAligned,
+                Severity: SeverityError,
+                Cell:     above[k][0],
+                Element:  elementId(e),
+                Expected: e.Regex,
+                Message:  fmt.Sprintf("%s `%d:%s` %v", ErrHeaderNotAligned, e.Id, e.Name, above[k]),
+                Err:      ErrHeaderNotAligned,
+            })
+        default:
+            ws.diagnose(Diagnostic{
+                Code:     CodeHeaderNotFound,
+                Severity: SeverityError,
+                Element:  elementId(e),
+                Expected: e.Regex,
+                Message:  fmt.Sprintf("%s `%d:%s` %v", ErrHeaderNotFound, e.Id, e.Name, c),
+                Err:      ErrHeaderNotFound,
+            })
+        }
+    }
+
+    ws.IsValid = true
+    ws.HeaderRow = hb.bottom
+    ws.GraphNCols = len(ws.Headers)
+    ws.diagnose(Diagnostic{
+        Code:     CodeHeaderAligned,
+        Severity: SeverityInfo,
+        Message:  fmt.Sprintf("%s %v", InfoHeaderAligned, ws.Headers),
+    })
+}
+
+// reportHazopHeaders reports the header matches of a worksheet without a
+// header block.
+func (wb *Workbook) reportHazopHeaders(ws *Worksheet, coords map[int][]string) {
+    ws.Headers = make(map[int]string)
+    for _, k := range ws.ElementIds() {
+        e := ws.Elements[k]
+        c := coords[k]
+
+        switch len(c) {
+        case 0:
+            ws.diagnose(Diagnostic{
+                Code:     CodeHeaderNotFound,
+                Severity: SeverityError,
+                Element:  elementId(e),
+                Expected: e.Regex,
+                Message:  fmt.Sprintf("%s `%d:%s` %v", ErrHeaderNotFound, e.Id, e.Name, c),
+                Err:      ErrHeaderNotFound,
+            })
+        case 1:
+            ws.Headers[e.Id] = c[0]
+            ws.diagnose(Diagnostic{
+                Code:     CodeHeaderFound,
+                Severity: SeverityInfo,
+                Cell:     c[0],
+                Element:  elementId(e),
+                Message:  fmt.Sprintf("%s `%d:%s` %v", InfoHeaderFound, e.Id, e.Name, c),
+            })
+        default:
+            ws.diagnose(Diagnostic{
+                Code:     CodeHeaderMulCoords,
+                Severity: SeverityError,
+                Cell:     c[0],
+                Element:  elementId(e),
+                Expected: e.Regex,
+                Message:  fmt.Sprintf("%s `%d:%s` %v", ErrHeaderMulCoords, e.Id, e.Name, c),
+                Err:      ErrHeaderMulCoords,
+            })
+        }
+    }
+
+    if len(ws.Headers) < 2 {
+        ws.diagnose(Diagnostic{
+            Code:     CodeNoHeader,
+            Severity: SeverityError,
+            Message:  ErrNoHeaderFound.Error(),
+            Err:      ErrNoHeaderFound,
+        })
+        return
+    }
+
+    ws.diagnose(Diagnostic{
+        Code:     CodeHeaderNotAligned,
+        Severity: SeverityError,
+        Message:  fmt.Sprintf("%s %v", ErrHeaderNotAligned, ws.Headers),
+        Err:      ErrHeaderNotAligned,
+    })
+}
@@ -0,0 +1,162 @@
+package importer
+
+import (
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestMultiRowHeaders(t *testing.T) {
+    assert := assert.New(t)
+
+    elements := Hazop.Elements
+    defer func() { Hazop.Elements = elements }()
+    Hazop.Elements = []HazopElement{
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", MinLen: 1, MaxLen: 80},
+        {Id: 6, Name: "Cause", Regex: "^(?i)(cause)", MinLen: 1, MaxLen: 160},
+        {Id: 9, Name: "ActionReference", Regex: "^(?i)(action|recommendation)\\s?(ref.?|no.?)$", DataType: "integer", MinLen: 1, MaxLen: 1000},
+        {Id: 10, Name: "Action", Regex: "^(?i)(action|recommendation)(\\s?description)?$", MinLen: 1, MaxLen: 160},
+        {Id: 11, Name: "ActionOn", Regex: "^(?i)(action|recommendation)\\s?on.?$", MinLen: 1, MaxLen: 40},
+        {Id: 12, Name: "Severity", Regex: "^(?i)(severity)", DataType: "integer", MinLen: 1, MaxLen: 100},
+        {Id: 14, Name: "RiskPriority", Regex: "^(?i)(risk\\s?priority)", MinLen: 1, MaxLen: 40},
+    }
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Node 1: Feed pump"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Deviation", "Cause", "Risk", nil, "Action"})
+    f.SetSheetRow("Sheet1", "A3", &[]interface{}{nil, nil, "Severity", "Risk Priority", "No.", "Description", "On"})
+    f.SetSheetRow("Sheet1", "A4", &[]interface{}{"No flow", "Pump fails", 3, "High", 1, "Add low flow alarm", "Process"})
+    f.SetSheetRow("Sheet1", "A5", &[]interface{}{"More flow", "Valve fails open", 2, "Low", 2, "Check relief sizing", "Design"})
+    f.MergeCell("Sheet1", "A1", "G1")
+    f.MergeCell("Sheet1", "A2", "A3")
+    f.MergeCell("Sheet1", "B2", "B3")
+    f.MergeCell("Sheet1", "C2", "D2")
+    f.MergeCell("Sheet1", "E2", "G2")
+
+    fpath := filepath.Join(t.TempDir(), "headers.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws := wb.Worksheets[0]
+    assert.True(ws.IsValid)
+    assert.Equal(3, ws.HeaderRow)
+    assert.Equal(map[int]string{
+        5: "A2", 6: "B2", 9: "E3", 10: "F3", 11: "G3", 12: "C3", 14: "D3",
+    }, ws.Headers)
+    assert.Equal(map[int]string{
+        5:  "Deviation",
+        6:  "Cause",
+        9:  "Action No.",
+        10: "Action Description",
+        11: "Action On",
+        12: "Risk Severity",
+        14: "Risk Risk Priority",
+    }, ws.HeaderPaths)
+    assert.Equal(2, ws.GraphNRows)
+    assert.Equal(1, ws.Graph[0]["ActionReference"])
+    assert.Equal("Add low flow alarm", ws.Graph[0]["Action"])
+    assert.Equal(3, ws.Graph[0]["Severity"])
+    assert.Equal("Design", ws.Graph[1]["ActionOn"])
+    assert.Empty(ws.Report.Errors())
+}
+
+func TestSingleRowHeaderWithGroupedData(t *testing.T) {
+    assert := assert.New(t)
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Ref", "Deviation", "Cause", "Consequence"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{1, "No flow", "Pump fails", "Loss of supply"})
+    f.SetSheetRow("Sheet1", "A3", &[]interface{}{2, "Less flow", "Pump worn", "Loss of supply"})
+    f.MergeCell("Sheet1", "D1", "E1")
+    f.MergeCell("Sheet1", "D2", "D3")
+
+    fpath := filepath.Join(t.TempDir(), "grouped.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws := wb.Worksheets[0]
+    assert.Equal(1, ws.HeaderRow)
+    assert.Equal("Consequence", ws.HeaderPaths[7])
+    assert.Equal(2, ws.GraphNRows)
+    assert.Equal(1, ws.Graph[0]["Reference"])
+    assert.Equal("Loss of supply", ws.Graph[1]["Consequence"])
+}
+
+func TestHeaderCandidates(t *testing.T) {
+    assert := assert.New(t)
+
+    elements := Hazop.Elements
+    defer func() { Hazop.Elements = elements }()
+    Hazop.Elements = []HazopElement{
+        {Id: 2, Name: "Reference", Regex: "^(?i)(ref.?|no.?)", DataType: "integer", MinLen: 1, MaxLen: 320},
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", MinLen: 1, MaxLen: 80},
+        {Id: 6, Name: "Cause", Regex: "^(?i)(cause)", MinLen: 1, MaxLen: 160},
+        {Id: 9, Name: "ActionReference", Regex: "^(?i)(action|recommendation)\\s?(ref.?|no.?)$", DataType: "integer", MinLen: 1, MaxLen: 1000, Priority: 1},
+    }
+
+    tests := []struct {
+        name     string
+        row1     []interface{}
+        row2     []interface{}
+        merges   [][2]string
+        headers  map[int]string
+        warnings []string
+    }{
+        {
+            name:    "exact over partial",
+            row1:    []interface{}{"Deviation", "Causes and effects", "Cause"},
+            headers: map[int]string{5: "A1", 6: "C1"},
+            warnings: []string{
+                WarnHeaderCandidates + " `6:Cause` chosen C1 rejected [B1]",
+            },
+        },
+        {
+            name:    "topmost row",
+            row1:    []interface{}{"Ref.", "Deviation", "Action"},
+            row2:    []interface{}{nil, nil, "No."},
+            merges:  [][2]string{{"A1", "A2"}, {"B1", "B2"}},
+            headers: map[int]string{2: "A1", 5: "B1", 9: "C2"},
+            warnings: []string{
+                WarnHeaderCandidates + " `2:Reference` chosen A1 rejected [C2]",
+            },
+        },
+        {
+            name:    "priority",
+            row1:    []interface{}{"Deviation", "Action"},
+            row2:    []interface{}{nil, "No."},
+            merges:  [][2]string{{"A1", "A2"}},
+            headers: map[int]string{5: "A1", 9: "B2"},
+            warnings: []string{
+                WarnHeaderCandidates + " `2:Reference` chosen none rejected [B2]",
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        f := excelize.NewFile()
+        f.SetSheetRow("Sheet1", "A1", &tt.row1)
+        if tt.row2 != nil {
+            f.SetSheetRow("Sheet1", "A2", &tt.row2)
+        }
+        for _, m := range tt.merges {
+            f.MergeCell("Sheet1", m[0], m[1])
+        }
+        f.SetSheetRow("Sheet1", "A3", &[]interface{}{1, "No flow", "Pump fails"})
+
+        fpath := filepath.Join(t.TempDir(), "candidates.xlsx")
+        assert.Empty(f.SaveAs(fpath), tt.name)
+
+        wb, err := ImportWorkbook(fpath)
+        assert.Empty(err, tt.name)
+
+        ws := wb.Worksheets[0]
+        assert.Equal(tt.headers, ws.Headers, tt.name)
+        assert.Equal(tt.warnings, ws.Report.Warnings(), tt.name)
+    }
+}
@@ -1,324 +1,564 @@
 package importer
 
 import (
+    "context"
+    "errors"
     "fmt"
-    "log"
     "math"
+    "regexp"
+    "runtime"
+    "sort"
     "sync"
 
     "github.com/xuri/excelize/v2"
 )
 
 var (
-    ErrNoHeaderFound    = "Error no header found"
-    ErrHeaderNotAligned = "Error header not aligned"
-    ErrHeaderNotFound   = "Error header not found"
-    ErrHeaderMulCoords  = "Error header multiple coordinates"
-    InfoHeaderAligned   = "Info header aligned"
-    InfoHeaderFound     = "Info header found"
-    InfoValueIsValid    = "Info value parsed/verified"
+    ErrNoHeaderFound    = errors.New("Error no header found")
+    ErrHeaderNotAligned = errors.New("Error header not aligned")
+    ErrHeaderNotFound   = errors.New("Error header not found")
+    ErrHeaderMulCoords  = errors.New("Error header multiple coordinates")
+    ErrReadingSheet     = errors.New("Error reading worksheet")
+    ErrHeaderRegex      = errors.New("Error compiling header regex")
+)
+
+var (
+    WarnHeaderCandidates = "Warning header multiple candidates"
+    InfoHeaderAligned    = "Info header aligned"
+    InfoHeaderFound      = "Info header found"
+    InfoValueIsValid     = "Info value parsed/verified"
+    InfoValueInherited   = "Info value inherited"
 )
 
 type Workbook struct {
     File          *excelize.File
-    SheetMap      map[int]string
-    Worksheets    map[int]*Worksheet
+    SheetList     []string
+    Worksheets    []*Worksheet
     HazopElements map[int]HazopElement
+    regexps       map[int]*regexp.Regexp
+    splits        map[int]*regexp.Regexp
+    overrides     []override
+    mapping       *Mapping
+    date1904      bool
 }
 
 type Worksheet struct {
-    Index       int
-    Name        string
-    NCols       int
-    NRows       int
-    NCells      int
-    NValidCells int
-    PValidCells float64
-    Graph       []map[string]interface{}
-    GraphNRows  int
-    GraphNCols  int
-    Headers     map[int]string
-    HeaderX     map[int]int
-    HeaderY     map[int]int
-    IsValid     bool
-    Report      *Report
+    Index           int
+    Name            string
+    NCols           int
+    NRows           int
+    NCells          int
+    NValidCells     int
+    PValidCells     float64
+    Graph           []map[string]interface{}
+    GraphNRows      int
+    GraphNCols      int
+    Headers         map[int]string
+    HeaderPaths     map[int]string
+    HeaderCells     map[string]string
+    HeaderX         map[int]int
+    HeaderY         map[int]int
+    HeaderRow       int
+    Inherited       map[string]string
+    IsValid         bool
+    Report          *Report
+    Elements        map[int]HazopElement
+    regexps         map[int]*regexp.Regexp
+    splits          map[int]*regexp.Regexp
+    forcedHeaderRow int
 }
 
-type Report struct {
-    Warnings []string
-    Errors   []string
-    Info     []string
+// ElementIds returns the hazop element ids in ascending order.
+func (wb *Workbook) ElementIds() []int {
+    ids := make([]int, 0, len(wb.HazopElements))
+    for k := range wb.HazopElements {
+        ids = append(ids, k)
+    }
+    sort.Ints(ids)
+    return ids
 }
 
-func (r *Report) NewWarning(msg string) {
-    r.Warnings = append(r.Warnings, msg)
+// ElementIds returns the ids of the worksheet elements in ascending order.
+func (ws *Worksheet) ElementIds() []int {
+    ids := make([]int, 0, len(ws.Elements))
+    for k := range ws.Elements {
+        ids = append(ids, k)
+    }
+    sort.Ints(ids)
+    return ids
 }
 
-func (r *Report) NewError(msg string) {
-    r.Errors = append(r.Errors, msg)
+// HeaderIds returns the hazop element ids of the found headers in
+// ascending order.
+func (ws *Worksheet) HeaderIds() []int {
+    ids := make([]int, 0, len(ws.Headers))
+    for k := range ws.Headers {
+        ids = append(ids, k)
+    }
+    sort.Ints(ids)
+    return ids
 }
 
-func (r *Report) NewInfo(msg string) {
-    r.Info = append(r.Info, msg)
+// Row returns the worksheet row number of the graph row i.
+func (ws *Worksheet) Row(i int) int {
+    return ws.HeaderRow + 1 + i
 }
 
 type HazopElement struct {
-    Id       int    `mapstructure:"id"`
-    Name     string `mapstructure:"name"`
-    Regex    string `mapstructure:"regex"`
-    DataType int    `mapstructure:"data_type"`
-    MinLen   int    `mapstructure:"min_len"`
-    MaxLen   int    `mapstructure:"max_len"`
+    Id         int      `mapstructure:"id"`
+    Name       string   `mapstructure:"name"`
+    Regex      string   `mapstructure:"regex"`
+    DataType   string   `mapstructure:"data_type"`
+    Values     []string `mapstructure:"values"`
+    Vocabulary string   `mapstructure:"vocabulary"`
+    MinLen     int      `mapstructure:"min_len"`
+    MaxLen     int      `mapstructure:"max_len"`
+    MinValue   *float64 `mapstructure:"min_value"`
+    MaxValue   *float64 `mapstructure:"max_value"`
+    Decimal    string   `mapstructure:"decimal"`
+    Layouts    []string `mapstructure:"layouts"`
+    Split      string   `mapstructure:"split"`
+    MinDate    string   `mapstructure:"min_date"`
+    MaxDate    string   `mapstructure:"max_date"`
+    Lang       string   `mapstructure:"lang"`
+    FillDown   bool     `mapstructure:"fill_down"`
+    Priority   int      `mapstructure:"priority"`
+}
+
+// Type returns the data type name of the element, the former numeric data
+// types 0, 1 and 2 are string, integer and float.
+func (e HazopElement) Type() string {
+    if t, ok := legacyTypes[e.DataType]; ok {
+        return t
+    }
+    return e.DataType
 }
 
 type HazopElements struct {
-    Elements []HazopElement `mapstructure:"elements"`
+    Elements  []HazopElement `mapstructure:"elements"`
+    Overrides []Override     `mapstructure:"overrides"`
 }
 
 var Hazop HazopElements
 
+// Options configure the import of a workbook.
+type Options struct {
+    // Workers is the number of worksheets read concurrently, values below
+    // one use the number of CPUs.
+    Workers int
+    // Mapping assigns header cells by hand, nil reads the mapping sidecar
+    // file of the workbook if it exists.
+    Mapping *Mapping
+    // Hazop holds the elements and overrides of the import, nil uses the
+    // manifest elements Hazop.
+    Hazop *HazopElements
+}
+
 func ImportWorkbook(fpath string) (*Workbook, error) {
-    wb, err := initHazopWorkbook(fpath)
+    return ImportWorkbookContext(context.Background(), fpath, Options{})
+}
+
+// ImportWorkbookContext reads and verifies all worksheets of the workbook
+// with a bounded pool of workers. Errors of a single worksheet are recorded
+// in its report, the import stops with the context error on cancellation.
+func ImportWorkbookContext(ctx context.Context, fpath string, opts Options) (*Workbook, error) {
+    mapping := opts.Mapping
+    if mapping == nil {
+        m, err := ReadMapping(MappingPath(fpath))
+        if err != nil {
+            return nil, err
+        }
+        mapping = m
+    }
+
+    hazop := opts.Hazop
+    if hazop == nil {
+        hazop = &Hazop
+    }
+
+    wb, err := initHazopWorkbook(fpath, hazop)
     if err != nil {
         return nil, err
     }
+    wb.mapping = mapping
 
-    if err := wb.readVerifyHazopWorkbook(); err != nil {
+    if err := wb.readVerifyHazopWorkbook(ctx, opts.Workers); err != nil {
         return nil, err
     }
 
     return wb, nil
 }
 
-func initHazopWorkbook(fpath string) (*Workbook, error) {
+func initHazopWorkbook(fpath string, hazop *HazopElements) (*Workbook, error) {
     f, err := excelize.OpenFile(fpath)
     if err != nil {
         return nil, err
     }
 
-    var hazopElements = make(map[int]HazopElement, len(Hazop.Elements))
-    for _, e := range Hazop.Elements {
+    var hazopElements = make(map[int]HazopElement, len(hazop.Elements))
+    var regexps = make(map[int]*regexp.Regexp, len(hazop.Elements))
+    var splits = make(map[int]*regexp.Regexp)
+    for _, e := range hazop.Elements {
+        re, err := regexp.Compile(e.Regex)
+        if err != nil {
+            f.Close()
+            return nil, fmt.Errorf("%w `%d:%s`: %v", ErrHeaderRegex, e.Id, e.Name, err)
+        }
         hazopElements[e.Id] = e
+        regexps[e.Id] = re
+
+        split, err := compileSplit(e)
+        if err != nil {
+            f.Close()
+            return nil, err
+        }
+        if split != nil {
+            splits[e.Id] = split
+        }
     }
 
-    var sheetMap = f.GetSheetMap()
-    log.Println(sheetMap)
+    overrides, err := workbookOverrides(fpath, hazop.Overrides)
+    if err != nil {
+        f.Close()
+        return nil, err
+    }
+
+    var sheetList = f.GetSheetList()
     var wb = &Workbook{
         File:          f,
         HazopElements: hazopElements,
-        SheetMap:      sheetMap,
-        Worksheets:    make(map[int]*Worksheet, len(sheetMap)),
+        SheetList:     sheetList,
+        Worksheets:    make([]*Worksheet, 0, len(sheetList)),
+        regexps:       regexps,
+        splits:        splits,
+        overrides:     overrides,
+        date1904:      f.WorkBook != nil && f.WorkBook.WorkbookPr != nil && f.WorkBook.WorkbookPr.Date1904,
     }
 
     return wb, nil
 }
 
-func (wb *Workbook) initWorksheet(i int, name string) (*Worksheet, error) {
-    cols, err := wb.File.GetCols(name)
+func (wb *Workbook) readVerifyHazopWorkbook(ctx context.Context, workers int) error {
+    if workers < 1 {
+        workers = runtime.NumCPU()
+    }
+
+    var wg sync.WaitGroup
+    jobs := make(chan int)
+
+    // every job owns one slot, so sheets keep the workbook order
+    worksheets := make([]*Worksheet, len(wb.SheetList))
+    for w := 0; w < workers; w++ {
+        wg.Add(1)
+
+        go func() {
+            defer wg.Done()
+
+            for i := range jobs {
+                worksheets[i] = wb.readVerifyHazopWorksheet(ctx, i)
+            }
+        }()
+    }
+
+loop:
+    for i := range wb.SheetList {
+        select {
+        case jobs <- i:
+        case <-ctx.Done():
+            break loop
+        }
+    }
+    close(jobs)
+    wg.Wait()
+
+    if err := wb.File.Close(); err != nil {
+        return err
+    }
+
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+
+    // skipped worksheets have no slot
+    for _, ws := range worksheets {
+        if ws != nil {
+            wb.Worksheets = append(wb.Worksheets, ws)
+        }
+    }
+
+    return nil
+}
+
+// readVerifyHazopWorksheet reads and verifies the i-th worksheet, skipped
+// worksheets are nil. A failing step marks the worksheet invalid and is
+// recorded in its report.
+func (wb *Workbook) readVerifyHazopWorksheet(ctx context.Context, i int) *Worksheet {
+    name := wb.SheetList[i]
+    ws := &Worksheet{Index: i + 1, Name: name, Report: &Report{}}
+
+    skip, err := wb.initSheetElements(ws)
+    if skip {
+        return nil
+    }
+
+    if err == nil {
+        err = wb.streamHazopWorksheet(ctx, ws)
+    }
+
     if err != nil {
-        return nil, err
+        ws.IsValid = false
+        ws.diagnose(Diagnostic{
+            Code:     CodeReadingSheet,
+            Severity: SeverityError,
+            Message:  fmt.Sprintf("%s `%s`: %v", ErrReadingSheet, name, err),
+            Err:      ErrReadingSheet,
+        })
     }
 
-    rows, err := wb.File.GetRows(name)
+    return ws
+}
+
+// streamHazopWorksheet iterates the rows of the worksheet once. Rows are
+// searched for the header block until it is found, the rows below are
+// verified in the same pass.
+func (wb *Workbook) streamHazopWorksheet(ctx context.Context, ws *Worksheet) error {
+    m, err := wb.readMerges(ws.Name)
     if err != nil {
-        return nil, err
+        return err
     }
 
-    ws := &Worksheet{
-        Index:  i,
-        Name:   name,
-        NCols:  len(cols),
-        NRows:  len(rows),
-        NCells: len(cols) * len(rows),
-        Report: &Report{},
+    rows, err := wb.File.Rows(ws.Name)
+    if err != nil {
+        return err
     }
+    defer rows.Close()
 
-    return ws, nil
-}
+    var st *sheetState
+    hs := &headerSearch{coords: make(map[int][]string)}
 
-func (wb *Workbook) readVerifyHazopWorkbook() error {
-    var wg sync.WaitGroup
+    for y := 1; rows.Next(); y++ {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
 
-    for i, name := range wb.SheetMap {
-        wg.Add(1)
+        cols, err := rows.Columns()
+        if err != nil {
+            return err
+        }
+        m.read(y, cols)
 
-        go func(i int, name string) {
-            defer wg.Done()
+        if len(cols) > 0 {
+            ws.NRows = y
+        }
+        if len(cols) > ws.NCols {
+            ws.NCols = len(cols)
+        }
 
-            ws, err := wb.initWorksheet(i, name)
-            if err != nil {
-                log.Println(err)
-                return
+        if st != nil {
+            if err := wb.streamHazopRow(ws, st, y, cols); err != nil {
+                return err
             }
+            continue
+        }
 
-            if err := wb.searchHazopHeaders(ws); err != nil {
-                log.Println(err)
-                return
-            }
+        if hs.done {
+            continue
+        }
 
-            if err := ws.testHeadersAlignment(); err != nil {
-                log.Println(err)
-                return
-            }
+        hb, below := wb.searchHazopHeaders(ws, hs, m, y, cols)
+        if hb == nil {
+            continue
+        }
 
-            if ws.IsValid {
-                if err := wb.readVerifyHazopData(ws); err != nil {
-                    log.Println(err)
-                    return
-                }
-            }
+        if st, err = wb.acceptHazopHeaders(ws, hs, hb, below, m); err != nil {
+            return err
+        }
+    }
 
-            wb.Worksheets[i] = ws
-        }(i, name)
+    if err := rows.Error(); err != nil {
+        return err
     }
 
-    wg.Wait()
+    if st == nil && hs.pending != nil {
+        hb, below := hs.accept()
+        if st, err = wb.acceptHazopHeaders(ws, hs, hb, below, m); err != nil {
+            return err
+        }
+    }
 
-    if err := wb.File.Close(); err != nil {
-        return err
+    ws.NCells = ws.NCols * ws.NRows
+
+    if st == nil {
+        for _, r := range hs.rows {
+            hs.drop(r)
+        }
+        wb.reportHazopHeaders(ws, hs.coords)
+        return nil
     }
 
+    ws.GraphNRows = len(ws.Graph)
+    ws.PValidCells = math.Round(
+        float64(ws.NValidCells)/float64(ws.NCells)*10000) / 100
+
     return nil
 }
 
-func (wb *Workbook) searchHazopHeaders(ws *Worksheet) error {
-    ws.Headers = make(map[int]string)
-    for _, e := range wb.HazopElements {
-        coords, err := wb.File.SearchSheet(ws.Name, e.Regex, true)
-        if err != nil {
-            return err
-        }
+// acceptHazopHeaders sets the headers of the found block and verifies the
+// rows read below it.
+func (wb *Workbook) acceptHazopHeaders(ws *Worksheet, hs *headerSearch, hb *headerBlock, below []headerRow, m *merges) (*sheetState, error) {
+    wb.setHazopHeaders(ws, hb, hs.coords)
 
-        switch len(coords) {
-        case 0:
-            ws.Report.NewError(fmt.Sprintf("%s `%d:%s` %v",
-                ErrHeaderNotFound,
-                e.Id,
-                e.Name,
-                coords,
-            ))
-        case 1:
-            ws.Headers[e.Id] = coords[0]
-            ws.Report.NewInfo(fmt.Sprintf("%s `%d:%s` %v",
-                InfoHeaderFound,
-                e.Id,
-                e.Name,
-                coords,
-            ))
-        default:
-            ws.Report.NewError(fmt.Sprintf("%s `%d:%s` %v",
-                ErrHeaderMulCoords,
-                e.Id,
-                e.Name,
-                coords,
-            ))
+    st, err := wb.newSheetState(ws, m)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, r := range below {
+        if err := wb.streamHazopRow(ws, st, r.y, r.cols); err != nil {
+            return nil, err
         }
     }
 
-    return nil
+    return st, nil
 }
 
-func (ws *Worksheet) testHeadersAlignment() error {
-    hlen := len(ws.Headers)
-    if hlen < 2 {
-        ws.IsValid = false
-        ws.Report.NewError(ErrNoHeaderFound)
+// streamHazopRow verifies row y below the header block. Empty rows are
+// verified once a row with values follows, so trailing empty rows are not
+// part of the table.
+func (wb *Workbook) streamHazopRow(ws *Worksheet, st *sheetState, y int, cols []string) error {
+    if len(cols) == 0 {
+        st.empty++
         return nil
     }
 
-    var headerX = make(map[int]int, hlen)
-    var headerY = make(map[int]int, hlen)
-    for k, v := range ws.Headers {
-        x, y, err := excelize.CellNameToCoordinates(v)
-        if err != nil {
+    for ; st.empty > 0; st.empty-- {
+        if err := wb.readVerifyHazopRow(ws, st, y-st.empty, nil); err != nil {
             return err
         }
-        headerX[k] = x
-        headerY[k] = y
     }
 
-    var k0 int
-    for k := range headerY {
-        if k0 == 0 {
-            k0 = k
-            continue
-        }
+    return wb.readVerifyHazopRow(ws, st, y, cols)
+}
 
-        if headerY[k0] != headerY[k] {
-            ws.IsValid = false
-            ws.Report.NewError(fmt.Sprintf("%s %v",
-                ErrHeaderNotAligned,
-                ws.Headers,
-            ))
-            return nil
+// cellValue is the value of a cell and the cell it was read from.
+type cellValue struct {
+    Cell  string
+    Value string
+}
+
+// sheetState is the state of the rows below the header row, k (key) - hazop
+// element id.
+type sheetState struct {
+    ids     []int
+    testers map[int]Tester
+    merged  *merges
+    last    map[int]cellValue
+    empty   int
+}
+
+func (wb *Workbook) newSheetState(ws *Worksheet, merged *merges) (*sheetState, error) {
+    ids := ws.HeaderIds()
+    testers := make(map[int]Tester, len(ids))
+    for _, k := range ids {
+        t, err := NewTester(ws.Elements[k])
+        if err != nil {
+            return nil, err
+        }
+        if d, ok := t.(testDate); ok {
+            d.date1904 = wb.date1904
+            t = d
         }
+        testers[k] = t
     }
 
-    ws.IsValid = true
-    ws.GraphNRows = ws.NRows - headerY[k0]
-    ws.GraphNCols = hlen
-    ws.HeaderX = headerX
-    ws.HeaderY = headerY
-    ws.Report.NewInfo(fmt.Sprintf("%s %v", InfoHeaderAligned, ws.Headers))
+    ws.Inherited = make(map[string]string)
+    st := &sheetState{
+        ids:     ids,
+        testers: testers,
+        merged:  merged,
+        last:    make(map[int]cellValue, len(ids)),
+    }
 
-    return nil
+    return st, nil
 }
 
-func (wb *Workbook) readVerifyHazopData(ws *Worksheet) error {
-    ws.Graph = make([]map[string]interface{}, ws.GraphNRows)
-    for i := 0; i < ws.GraphNRows; i++ {
-        ws.Graph[i] = make(map[string]interface{}, ws.GraphNCols)
-    }
-
-    // k (key) - hazop element id
-    for k := range ws.Headers {
-        tester, err := newTester(wb.HazopElements[k].DataType)
+// readVerifyHazopRow verifies the cells of row y below the header row, k
+// (key) - hazop element id in ascending order. Cells covered by a merged
+// range and blank cells of fill down elements inherit their value.
+func (wb *Workbook) readVerifyHazopRow(ws *Worksheet, st *sheetState, y int, cols []string) error {
+    row := make(map[string]interface{}, ws.GraphNCols)
+    for _, k := range st.ids {
+        e := ws.Elements[k]
+        x := ws.HeaderX[k]
+        cname, err := excelize.CoordinatesToCellName(x, y)
         if err != nil {
             return err
         }
 
-        for i := 0; i < ws.GraphNRows; i++ {
-            cname, err := excelize.CoordinatesToCellName(
-                ws.HeaderX[k],
-                ws.HeaderY[k]+1+i,
-            )
-            if err != nil {
-                return err
+        cv, ok := st.merged.cell(cname)
+        if !ok {
+            cv = cellValue{Cell: cname}
+            if x <= len(cols) {
+                cv.Value = cols[x-1]
             }
+        }
 
-            val, err := wb.File.GetCellValue(ws.Name, cname)
-            if err != nil {
-                return err
-            }
+        if cv.Value == "" && len(cols) > 0 && e.FillDown {
+            cv = st.last[k]
+        }
 
-            vparsed, err := tester.testCellType(val)
-            if err != nil {
-                ws.Report.NewError(fmt.Sprintf("%v `%v`", err, cname))
-                continue
-            }
+        if cv.Value != "" {
+            st.last[k] = cv
+        }
 
-            err = tester.testCellLength(
-                vparsed,
-                wb.HazopElements[k].MinLen,
-                wb.HazopElements[k].MaxLen,
-            )
-            if err != nil {
-                ws.Report.NewError(fmt.Sprintf("%v `%v`", err, cname))
-                continue
-            }
+        if cv.Cell != "" && cv.Cell != cname {
+            ws.Inherited[cname] = cv.Cell
+            ws.diagnose(Diagnostic{
+                Code:     CodeValueInherited,
+                Severity: SeverityInfo,
+                Cell:     cname,
+                Element:  elementId(e),
+                Value:    cv.Value,
+                Message:  fmt.Sprintf("%s: `%s` from `%s`", InfoValueInherited, cname, cv.Cell),
+            })
+        }
 
-            ws.Report.NewInfo(fmt.Sprintf("%s: `%s`",
-                InfoValueIsValid,
-                cname,
-            ))
+        vparsed, errs := testHazopCell(st.testers[k], e, ws.splits[k], cv.Value)
+        for _, err := range errs {
+            ws.diagnose(Diagnostic{
+                Code:     valueCode(err),
+                Severity: SeverityError,
+                Cell:     cname,
+                Element:  elementId(e),
+                Value:    cv.Value,
+                Expected: e.Constraint(),
+                Message:  fmt.Sprintf("%v `%v`", err, cname),
+                Err:      err,
+            })
+        }
+        if vparsed == nil {
+            continue
+        }
 
-            ws.NValidCells += 1
-            ws.Graph[i][wb.HazopElements[k].Name] = vparsed
+        // valid items of a partly invalid cell are kept
+        if len(errs) > 0 {
+            row[e.Name] = vparsed
+            continue
         }
-    }
 
-    ws.PValidCells = math.Round(
-        float64(ws.NValidCells)/float64(ws.NCells)*10000) / 100
+        ws.diagnose(Diagnostic{
+            Code:     CodeValueValid,
+            Severity: SeverityInfo,
+            Cell:     cname,
+            Element:  elementId(e),
+            Value:    cv.Value,
+            Message:  fmt.Sprintf("%s: `%s`", InfoValueIsValid, cname),
+        })
+
+        ws.NValidCells += 1
+        row[e.Name] = vparsed
+    }
+    ws.Graph = append(ws.Graph, row)
 
     return nil
 }
@@ -1,6 +1,8 @@
 package importer
 
 import (
+    "context"
+    "fmt"
     "io/ioutil"
     "log"
     "os"
@@ -9,6 +11,7 @@ import (
     "testing"
 
     "github.com/spf13/viper"
+    "github.com/xuri/excelize/v2"
     "github.com/stretchr/testify/assert"
 )
 
@@ -47,6 +50,89 @@ func BenchmarkReadVerifyWorkbook(b *testing.B) {
     }
 }
 
+// writeLargeWorkbook writes a synthetic workbook with one Hazop table of n
+// rows below a merged title row.
+func writeLargeWorkbook(fpath string, n int) error {
+    f := excelize.NewFile()
+    sw, err := f.NewStreamWriter("Sheet1")
+    if err != nil {
+        return err
+    }
+
+    if err := sw.SetRow("A1", []interface{}{"Site-wide Hazop"}); err != nil {
+        return err
+    }
+    if err := sw.MergeCell("A1", "M1"); err != nil {
+        return err
+    }
+
+    headers := []interface{}{
+        "Ref", "Guide Word", "Parameter", "Deviation", "Cause",
+        "Consequence", "Safeguard", "Action Ref", "Action", "Action On",
+        "Severity", "Probability", "Risk Priority",
+    }
+    if err := sw.SetRow("A2", headers); err != nil {
+        return err
+    }
+
+    for i := 1; i <= n; i++ {
+        row := []interface{}{
+            i, "More", "Flow", "More flow", fmt.Sprintf("Control valve %d fails open", i),
+            "Overpressure of vessel", "Relief valve", i, "Check relief sizing", "Process",
+            i%5 + 1, "Likely", "High",
+        }
+        cell, _ := excelize.CoordinatesToCellName(1, i+2)
+        if err := sw.SetRow(cell, row); err != nil {
+            return err
+        }
+    }
+
+    if err := sw.Flush(); err != nil {
+        return err
+    }
+
+    return f.SaveAs(fpath)
+}
+
+// BenchmarkImportLargeWorkbook compares the streamed import with reading
+// the merged ranges and rows of every worksheet by GetMergeCells and GetRows.
+func BenchmarkImportLargeWorkbook(b *testing.B) {
+    for _, n := range []int{1000, 10000, 50000} {
+        fpath := filepath.Join(b.TempDir(), "large.xlsx")
+        if err := writeLargeWorkbook(fpath, n); err != nil {
+            b.Fatal(err)
+        }
+
+        b.Run(fmt.Sprintf("rows=%d/stream", n), func(b *testing.B) {
+            b.ReportAllocs()
+            for i := 0; i < b.N; i++ {
+                if _, err := ImportWorkbook(fpath); err != nil {
+                    b.Fatal(err)
+                }
+            }
+        })
+
+        b.Run(fmt.Sprintf("rows=%d/getrows", n), func(b *testing.B) {
+            b.ReportAllocs()
+            for i := 0; i < b.N; i++ {
+                f, err := excelize.OpenFile(fpath)
+                if err != nil {
+                    b.Fatal(err)
+                }
+                for _, name := range f.GetSheetList() {
+                    if _, err := f.GetMergeCells(name); err != nil {
+                        b.Fatal(err)
+                    }
+                    if _, err := f.GetRows(name); err != nil {
+                        b.Fatal(err)
+                    }
+                }
+                f.Close()
+            }
+        })
+    }
+}
+
 func TestReadVerifyWorkbook(t *testing.T) {
     assert := assert.New(t)
 
@@ -67,3 +153,130 @@ func TestReadVerifyWorkbook(t *testing.T) {
         }
     }
 }
+
+func TestReadVerifyWorkbookOrder(t *testing.T) {
+    assert := assert.New(t)
+
+    fpath := filepath.Join("hazop", "HazopCrawleyGuideToBestPracticeShort.xlsx")
+    wb0, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    var names []string
+    for i, ws := range wb0.Worksheets {
+        assert.Equal(i+1, ws.Index)
+        names = append(names, ws.Name)
+    }
+    assert.Equal(wb0.SheetList, names)
+
+    for i := 0; i < 5; i++ {
+        wb, err := ImportWorkbook(fpath)
+        assert.Empty(err)
+        assert.Equal(len(wb0.Worksheets), len(wb.Worksheets))
+        for j, ws := range wb.Worksheets {
+            assert.Equal(wb0.Worksheets[j].Report, ws.Report)
+            assert.Equal(wb0.Worksheets[j].Graph, ws.Graph)
+        }
+    }
+}
+
+func TestImportWorkbookWorkers(t *testing.T) {
+    assert := assert.New(t)
+
+    fpath := filepath.Join("hazop", "HazopCrawleyGuideToBestPracticeShort.xlsx")
+    wb0, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    for _, n := range []int{1, 2, 16} {
+        wb, err := ImportWorkbookContext(context.Background(), fpath, Options{Workers: n})
+        assert.Empty(err)
+        assert.Equal(len(wb0.Worksheets), len(wb.Worksheets))
+        for j, ws := range wb.Worksheets {
+            assert.Equal(wb0.Worksheets[j].Report, ws.Report)
+            assert.Equal(wb0.Worksheets[j].Graph, ws.Graph)
+        }
+    }
+}
+
+func TestImportWorkbookCancel(t *testing.T) {
+    assert := assert.New(t)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    fpath := filepath.Join("hazop", "HazopCrawleyGuideToBestPracticeShort.xlsx")
+    wb, err := ImportWorkbookContext(ctx, fpath, Options{Workers: 2})
+    assert.ErrorIs(err, context.Canceled)
+    assert.Empty(wb)
+}
+
+func TestStreamWorksheet(t *testing.T) {
+    assert := assert.New(t)
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Cause and effect study"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Ref", "Deviation", "Cause"})
+    f.SetSheetRow("Sheet1", "A3", &[]interface{}{1, "No flow", "Pump fails"})
+    f.SetSheetRow("Sheet1", "A4", &[]interface{}{2, "More flow", "Valve fails open"})
+    f.SetSheetRow("Sheet1", "A5", &[]interface{}{3, nil, "Cause unknown"})
+    f.SetSheetRow("Sheet1", "A7", &[]interface{}{4, "Less flow", "Leak"})
+    f.MergeCell("Sheet1", "B4", "B5")
+    f.SetCellStyle("Sheet1", "A9", "C9", 0)
+
+    fpath := filepath.Join(t.TempDir(), "stream.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+    assert.Len(wb.Worksheets, 1)
+
+    ws := wb.Worksheets[0]
+    assert.True(ws.IsValid)
+    assert.Equal(2, ws.HeaderRow)
+    assert.Equal(map[int]string{2: "A2", 5: "B2", 6: "C2"}, ws.Headers)
+    assert.Equal(7, ws.NRows)
+    assert.Equal(3, ws.NCols)
+    assert.Equal(5, ws.GraphNRows)
+
+    assert.Equal("More flow", ws.Graph[2]["Deviation"])
+    assert.Equal("Cause unknown", ws.Graph[2]["Cause"])
+    assert.Empty(ws.Graph[3])
+    assert.Equal("Leak", ws.Graph[4]["Cause"])
+    assert.Equal(7, ws.Row(4))
+}
+
+func TestFillDown(t *testing.T) {
+    assert := assert.New(t)
+
+    elements := Hazop.Elements
+    defer func() { Hazop.Elements = elements }()
+    Hazop.Elements = []HazopElement{
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", MinLen: 1, MaxLen: 80, FillDown: true},
+        {Id: 6, Name: "Cause", Regex: "^(?i)(cause)", MinLen: 1, MaxLen: 160},
+        {Id: 7, Name: "Consequence", Regex: "^(?i)(consequence)", MinLen: 1, MaxLen: 160},
+    }
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Deviation", "Cause", "Consequence"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"No flow", "Pump fails", "Loss of supply"})
+    f.SetSheetRow("Sheet1", "A3", &[]interface{}{nil, nil, "Overheating of pump"})
+    f.SetSheetRow("Sheet1", "A4", &[]interface{}{nil, "Valve closed", "Loss of supply"})
+    f.SetSheetRow("Sheet1", "A6", &[]interface{}{"More flow", "Valve fails open", "Overpressure"})
+    f.MergeCell("Sheet1", "B2", "B3")
+
+    fpath := filepath.Join(t.TempDir(), "filldown.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws := wb.Worksheets[0]
+    assert.Equal(5, ws.GraphNRows)
+    assert.Equal("No flow", ws.Graph[1]["Deviation"])
+    assert.Equal("Pump fails", ws.Graph[1]["Cause"])
+    assert.Equal("No flow", ws.Graph[2]["Deviation"])
+    assert.Equal("Valve closed", ws.Graph[2]["Cause"])
+    assert.Empty(ws.Graph[3])
+    assert.Equal("More flow", ws.Graph[4]["Deviation"])
+    assert.Equal(map[string]string{"A3": "A2", "B3": "B2", "A4": "A2"}, ws.Inherited)
+    assert.Contains(ws.Report.Info(), InfoValueInherited+": `A4` from `A2`")
+}
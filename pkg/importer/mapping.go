@@ -0,0 +1,170 @@
+package importer
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/xuri/excelize/v2"
+)
+
+// MappingExt is the extension of the mapping sidecar file of a workbook.
+const MappingExt = ".mapping.json"
+
+var (
+    ErrReadingMapping = errors.New("Error reading header mapping")
+    ErrWritingMapping = errors.New("Error writing header mapping")
+)
+
+var (
+    WarnMappingIgnored = "Warning header mapping ignored"
+    InfoHeaderMapped   = "Info header mapped"
+)
+
+// Mapping assigns header cells to hazop elements by hand. Worksheets maps a
+// worksheet name to element names and their header cells.
+type Mapping struct {
+    Worksheets map[string]map[string]string `json:"worksheets"`
+}
+
+// MappingPath returns the path of the mapping sidecar file of the workbook.
+func MappingPath(fpath string) string {
+    return strings.TrimSuffix(fpath, filepath.Ext(fpath)) + MappingExt
+}
+
+// ReadMapping reads a mapping file, a missing file is an empty mapping.
+func ReadMapping(fpath string) (*Mapping, error) {
+    m := &Mapping{Worksheets: make(map[string]map[string]string)}
+
+    b, err := ioutil.ReadFile(fpath)
+    if errors.Is(err, os.ErrNotExist) {
+        return m, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w `%s`: %v", ErrReadingMapping, fpath, err)
+    }
+
+    if err := json.Unmarshal(b, m); err != nil {
+        return nil, fmt.Errorf("%w `%s`: %v", ErrReadingMapping, fpath, err)
+    }
+    if m.Worksheets == nil {
+        m.Worksheets = make(map[string]map[string]string)
+    }
+
+    return m, nil
+}
+
+// WriteFile writes the mapping as indented JSON.
+func (m *Mapping) WriteFile(fpath string) error {
+    b, err := json.MarshalIndent(m, "", "  ")
+    if err != nil {
+        return fmt.Errorf("%w `%s`: %v", ErrWritingMapping, fpath, err)
+    }
+
+    if err := ioutil.WriteFile(fpath, append(b, '\n'), 0644); err != nil {
+        return fmt.Errorf("%w `%s`: %v", ErrWritingMapping, fpath, err)
+    }
+
+    return nil
+}
+
+// Set assigns the header cell of the element in the worksheet.
+func (m *Mapping) Set(worksheet, element, cell string) {
+    if m.Worksheets[worksheet] == nil {
+        m.Worksheets[worksheet] = make(map[string]string)
+    }
+    m.Worksheets[worksheet][element] = cell
+}
+
+// Cell returns the header cell of the element in the worksheet.
+func (m *Mapping) Cell(worksheet, element string) (string, bool) {
+    cell, ok := m.Worksheets[worksheet][element]
+    return cell, ok
+}
+
+// UnmatchedIds returns the ids of the elements without a header in the
+// worksheet in ascending order.
+func (ws *Worksheet) UnmatchedIds() []int {
+    var ids []int
+    for _, k := range ws.ElementIds() {
+        if _, ok := ws.Headers[k]; !ok {
+            ids = append(ids, k)
+        }
+    }
+    return ids
+}
+
+// UnmatchedCells returns the cells of the header block not chosen by any
+// element from left to right.
+func (ws *Worksheet) UnmatchedCells() []string {
+    claimed := make(map[string]bool, len(ws.Headers))
+    for _, cell := range ws.Headers {
+        claimed[cell] = true
+    }
+
+    var cells []string
+    for cell := range ws.HeaderCells {
+        if !claimed[cell] {
+            cells = append(cells, cell)
+        }
+    }
+
+    sort.Slice(cells, func(i, j int) bool {
+        xi, yi, _ := excelize.CellNameToCoordinates(cells[i])
+        xj, yj, _ := excelize.CellNameToCoordinates(cells[j])
+        if xi != xj {
+            return xi < xj
+        }
+        return yi < yj
+    })
+
+    return cells
+}
+
+// mapHazopHeaders assigns the mapped cells to the elements of the worksheet
+// the block has no header for.
+func (wb *Workbook) mapHazopHeaders(ws *Worksheet, hb *headerBlock) map[int]bool {
+    mapped := make(map[int]bool)
+    if wb.mapping == nil {
+        return mapped
+    }
+
+    claimed := make(map[string]bool, len(hb.headers))
+    for _, cell := range hb.headers {
+        claimed[cell] = true
+    }
+
+    for _, k := range ws.ElementIds() {
+        e := ws.Elements[k]
+        cell, ok := wb.mapping.Cell(ws.Name, e.Name)
+        if !ok {
+            continue
+        }
+
+        if _, ok := hb.headers[k]; ok {
+            continue
+        }
+
+        if _, ok := hb.paths[cell]; !ok || claimed[cell] {
+            ws.diagnose(Diagnostic{
+                Code:     CodeMappingIgnored,
+                Severity: SeverityWarning,
+                Cell:     cell,
+                Element:  elementId(e),
+                Message:  fmt.Sprintf("%s `%d:%s` %v", WarnMappingIgnored, e.Id, e.Name, []string{cell}),
+            })
+            continue
+        }
+
+        hb.headers[k] = cell
+        claimed[cell] = true
+        mapped[k] = true
+    }
+
+    return mapped
+}
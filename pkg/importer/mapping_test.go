@@ -0,0 +1,82 @@
+package importer
+
+import (
+    "context"
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestMappingFile(t *testing.T) {
+    assert := assert.New(t)
+
+    fpath := filepath.Join(t.TempDir(), "hazop.xlsx")
+    assert.Equal(filepath.Join(filepath.Dir(fpath), "hazop.mapping.json"), MappingPath(fpath))
+
+    m, err := ReadMapping(MappingPath(fpath))
+    assert.Empty(err)
+    assert.Empty(m.Worksheets)
+
+    m.Set("Sheet1", "GuideWord", "B1")
+    assert.Empty(m.WriteFile(MappingPath(fpath)))
+
+    m, err = ReadMapping(MappingPath(fpath))
+    assert.Empty(err)
+    cell, ok := m.Cell("Sheet1", "GuideWord")
+    assert.True(ok)
+    assert.Equal("B1", cell)
+
+    _, ok = m.Cell("Sheet2", "GuideWord")
+    assert.False(ok)
+}
+
+func TestImportWorkbookMapping(t *testing.T) {
+    assert := assert.New(t)
+
+    elements := Hazop.Elements
+    defer func() { Hazop.Elements = elements }()
+    Hazop.Elements = []HazopElement{
+        {Id: 3, Name: "GuideWord", Regex: "^(?i)(guide\\s?word)", MinLen: 1, MaxLen: 40},
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", MinLen: 1, MaxLen: 80},
+        {Id: 6, Name: "Cause", Regex: "^(?i)(cause)", MinLen: 1, MaxLen: 160},
+        {Id: 7, Name: "Consequence", Regex: "^(?i)(consequence)", MinLen: 1, MaxLen: 160},
+    }
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Keyword", "Deviation", "Cause", "Result"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"No", "No flow", "Pump fails", "Loss of supply"})
+
+    fpath := filepath.Join(t.TempDir(), "mapping.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws := wb.Worksheets[0]
+    assert.Equal([]int{3, 7}, ws.UnmatchedIds())
+    assert.Equal([]string{"A1", "D1"}, ws.UnmatchedCells())
+
+    m, err := ReadMapping(MappingPath(fpath))
+    assert.Empty(err)
+    m.Set("Sheet1", "GuideWord", "A1")
+    m.Set("Sheet1", "Consequence", "B1")
+    assert.Empty(m.WriteFile(MappingPath(fpath)))
+
+    wb, err = ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws = wb.Worksheets[0]
+    assert.Equal("A1", ws.Headers[3])
+    assert.Equal("No", ws.Graph[0]["GuideWord"])
+    assert.Contains(ws.Report.Info(), InfoHeaderMapped+" `3:GuideWord` [A1]")
+    assert.Contains(ws.Report.Warnings(), WarnMappingIgnored+" `7:Consequence` [B1]")
+    assert.Equal([]int{7}, ws.UnmatchedIds())
+
+    m = &Mapping{}
+    m.Worksheets = map[string]map[string]string{"Sheet1": {"Consequence": "D1"}}
+    wb, err = ImportWorkbookContext(context.Background(), fpath, Options{Mapping: m})
+    assert.Empty(err)
+    assert.Equal(map[int]string{5: "B1", 6: "C1", 7: "D1"}, wb.Worksheets[0].Headers)
+}
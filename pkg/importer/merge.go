@@ -0,0 +1,268 @@
+package importer
+
+import (
+    "archive/zip"
+    "bufio"
+    "bytes"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "path"
+    "strings"
+
+    "github.com/xuri/excelize/v2"
+)
+
+var (
+    ErrSheetPart = errors.New("Error finding worksheet part")
+)
+
+// merges are the merged ranges of a worksheet. Values of the top left cells
+// are recorded while the rows are streamed.
+type merges struct {
+    // cells maps every covered cell, except the top left cell of a range, to
+    // the top left cell
+    cells map[string]string
+    // starts are the top left cells of the ranges by row and column
+    starts map[int]map[int]string
+    // values are the values of the top left cells of the rows read so far
+    values map[string]string
+    // spans are the rows continued by a range in the next row
+    spans map[int]bool
+    // groups are the rows in which a range over several columns ends
+    groups map[int]bool
+    // widths are the number of columns of a range by its top left cell
+    widths map[string]int
+}
+
+// readMerges reads the merged ranges of the worksheet from the workbook file.
+func (wb *Workbook) readMerges(name string) (*merges, error) {
+    refs, err := readMergeRefs(wb.File.Path, name)
+    if err != nil {
+        return nil, err
+    }
+
+    m := &merges{
+        cells:  make(map[string]string),
+        starts: make(map[int]map[int]string),
+        values: make(map[string]string),
+        spans:  make(map[int]bool),
+        groups: make(map[int]bool),
+        widths: make(map[string]int),
+    }
+    for _, ref := range refs {
+        axes := strings.Split(ref, ":")
+        if len(axes) != 2 {
+            continue
+        }
+        x0, y0, err := excelize.CellNameToCoordinates(axes[0])
+        if err != nil {
+            return nil, err
+        }
+        x1, y1, err := excelize.CellNameToCoordinates(axes[1])
+        if err != nil {
+            return nil, err
+        }
+        start, _ := excelize.CoordinatesToCellName(x0, y0)
+
+        if m.starts[y0] == nil {
+            m.starts[y0] = make(map[int]string)
+        }
+        m.starts[y0][x0] = start
+
+        for y := y0; y <= y1; y++ {
+            if y < y1 {
+                m.spans[y] = true
+            }
+
+            for x := x0; x <= x1; x++ {
+                if x == x0 && y == y0 {
+                    continue
+                }
+                cname, _ := excelize.CoordinatesToCellName(x, y)
+                m.cells[cname] = start
+            }
+        }
+
+        if x1 > x0 {
+            m.groups[y1] = true
+        }
+        m.widths[start] = x1 - x0 + 1
+    }
+
+    return m, nil
+}
+
+// read records the values of the ranges starting in row y.
+func (m *merges) read(y int, cols []string) {
+    for x, start := range m.starts[y] {
+        if x <= len(cols) {
+            m.values[start] = cols[x-1]
+        }
+    }
+}
+
+// cell returns the top left cell and its value of the range covering cname.
+func (m *merges) cell(cname string) (cellValue, bool) {
+    start, ok := m.cells[cname]
+    if !ok {
+        return cellValue{}, false
+    }
+    return cellValue{Cell: start, Value: m.values[start]}, true
+}
+
+// readMergeRefs returns the references of the merged ranges of the worksheet,
+// e.g. `A1:B2`. The worksheet part is scanned up to the mergeCells element,
+// which follows the rows, and only that element is decoded.
+func readMergeRefs(fpath, sheet string) ([]string, error) {
+    zr, err := zip.OpenReader(fpath)
+    if err != nil {
+        return nil, err
+    }
+    defer zr.Close()
+
+    part, err := sheetPart(&zr.Reader, sheet)
+    if err != nil {
+        return nil, err
+    }
+
+    var zf *zip.File
+    for _, f := range zr.File {
+        if f.Name == part {
+            zf = f
+            break
+        }
+    }
+    if zf == nil {
+        return nil, fmt.Errorf("%w `%s`: %s", ErrSheetPart, sheet, part)
+    }
+
+    rc, err := zf.Open()
+    if err != nil {
+        return nil, err
+    }
+    defer rc.Close()
+
+    br := bufio.NewReaderSize(rc, 64<<10)
+    found, err := seekElement(br, "mergeCells")
+    if err != nil || !found {
+        return nil, err
+    }
+
+    var refs []string
+    d := xml.NewDecoder(io.MultiReader(strings.NewReader("<"), br))
+    for {
+        tok, err := d.RawToken()
+        if err == io.EOF {
+            return refs, nil
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        switch t := tok.(type) {
+        case xml.StartElement:
+            if t.Name.Local != "mergeCell" {
+                continue
+            }
+            for _, a := range t.Attr {
+                if a.Name.Local == "ref" {
+                    refs = append(refs, a.Value)
+                }
+            }
+        case xml.EndElement:
+            if t.Name.Local == "mergeCells" {
+                return refs, nil
+            }
+        }
+    }
+}
+
+// seekElement advances br past the `<` of the next start element with the
+// local name, it reports false if there is none.
+func seekElement(br *bufio.Reader, local string) (bool, error) {
+    for {
+        _, err := br.ReadSlice('<')
+        if err == bufio.ErrBufferFull {
+            continue
+        }
+        if err == io.EOF {
+            return false, nil
+        }
+        if err != nil {
+            return false, err
+        }
+
+        name, err := br.Peek(len(local) + 32)
+        if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+            return false, err
+        }
+        if end := bytes.IndexAny(name, " \t\r\n/>"); end >= 0 {
+            name = name[:end]
+        }
+        if i := bytes.IndexByte(name, ':'); i >= 0 {
+            name = name[i+1:]
+        }
+        if string(name) == local {
+            return true, nil
+        }
+    }
+}
+
+// sheetPart returns the zip part of the worksheet, e.g.
+// `xl/worksheets/sheet1.xml`, from the workbook and its relationships.
+func sheetPart(zr *zip.Reader, sheet string) (string, error) {
+    var wb struct {
+        Sheets []struct {
+            Name string `xml:"name,attr"`
+            Id   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+        } `xml:"sheets>sheet"`
+    }
+    if err := readZipXML(zr, "xl/workbook.xml", &wb); err != nil {
+        return "", err
+    }
+
+    var rels struct {
+        Relationships []struct {
+            Id     string `xml:"Id,attr"`
+            Target string `xml:"Target,attr"`
+        } `xml:"Relationship"`
+    }
+    if err := readZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+        return "", err
+    }
+
+    for _, s := range wb.Sheets {
+        if s.Name != sheet {
+            continue
+        }
+        for _, r := range rels.Relationships {
+            if r.Id != s.Id {
+                continue
+            }
+            if strings.HasPrefix(r.Target, "/") {
+                return strings.TrimPrefix(r.Target, "/"), nil
+            }
+            return path.Join("xl", r.Target), nil
+        }
+    }
+
+    return "", fmt.Errorf("%w `%s`", ErrSheetPart, sheet)
+}
+
+// readZipXML unmarshals the zip part name into v.
+func readZipXML(zr *zip.Reader, name string, v interface{}) error {
+    for _, f := range zr.File {
+        if f.Name != name {
+            continue
+        }
+        rc, err := f.Open()
+        if err != nil {
+            return err
+        }
+        defer rc.Close()
+        return xml.NewDecoder(rc).Decode(v)
+    }
+    return fmt.Errorf("%w: %s", ErrSheetPart, name)
+}
@@ -0,0 +1,70 @@
+package importer
+
+import (
+    "bufio"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestReadMerges(t *testing.T) {
+    assert := assert.New(t)
+
+    f := excelize.NewFile()
+    f.NewSheet("Analysis")
+    f.SetSheetRow("Analysis", "A1", &[]interface{}{"Risk", "", "Notes"})
+    f.SetSheetRow("Analysis", "A2", &[]interface{}{"Severity", "Probability", ""})
+    f.MergeCell("Analysis", "A1", "B1")
+    f.MergeCell("Analysis", "C1", "C2")
+    fpath := filepath.Join(t.TempDir(), "merges.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    refs, err := readMergeRefs(fpath, "Analysis")
+    assert.Empty(err)
+    assert.ElementsMatch([]string{"A1:B1", "C1:C2"}, refs)
+
+    refs, err = readMergeRefs(fpath, "Sheet1")
+    assert.Empty(err)
+    assert.Empty(refs)
+
+    _, err = readMergeRefs(fpath, "Missing")
+    assert.ErrorIs(err, ErrSheetPart)
+
+    wb := &Workbook{File: &excelize.File{Path: fpath}}
+    m, err := wb.readMerges("Analysis")
+    assert.Empty(err)
+    assert.True(m.spans[1])
+    assert.True(m.groups[1])
+    assert.Equal(2, m.widths["A1"])
+
+    cv, ok := m.cell("B1")
+    assert.True(ok)
+    assert.Equal(cellValue{Cell: "A1"}, cv)
+
+    m.read(1, []string{"Risk", "", "Notes"})
+    cv, _ = m.cell("B1")
+    assert.Equal(cellValue{Cell: "A1", Value: "Risk"}, cv)
+    cv, _ = m.cell("C2")
+    assert.Equal(cellValue{Cell: "C1", Value: "Notes"}, cv)
+
+    _, ok = m.cell("A1")
+    assert.False(ok)
+}
+
+func TestSeekElement(t *testing.T) {
+    assert := assert.New(t)
+
+    br := bufio.NewReader(strings.NewReader(`<x:worksheet><x:sheetData><x:row r="1"/></x:sheetData><x:mergeCells count="1"><x:mergeCell ref="A1:B1"/></x:mergeCells></x:worksheet>`))
+    found, err := seekElement(br, "mergeCells")
+    assert.Empty(err)
+    assert.True(found)
+    rest, _ := br.ReadString('>')
+    assert.Equal(`x:mergeCells count="1">`, rest)
+
+    found, err = seekElement(bufio.NewReader(strings.NewReader(`<worksheet><mergeCell/></worksheet>`)), "mergeCells")
+    assert.Empty(err)
+    assert.False(found)
+}
@@ -0,0 +1,179 @@
+package importer
+
+import (
+    "errors"
+    "fmt"
+    "path/filepath"
+    "regexp"
+)
+
+var (
+    ErrOverridePattern = errors.New("Error compiling override pattern")
+)
+
+var (
+    InfoSheetOverride = "Info worksheet override"
+)
+
+// Override changes the elements of the worksheets whose workbook file name
+// matches the Workbook glob and whose name matches the Sheet regex, empty
+// patterns match all. Matching overrides apply in manifest order.
+type Override struct {
+    Workbook  string            `mapstructure:"workbook"`
+    Sheet     string            `mapstructure:"sheet"`
+    Skip      bool              `mapstructure:"skip"`
+    HeaderRow int               `mapstructure:"header_row"`
+    Elements  []ElementOverride `mapstructure:"elements"`
+}
+
+// ElementOverride changes the element with the same id, nil fields keep the
+// manifest value. Skip removes the element from the worksheet.
+type ElementOverride struct {
+    Id       int      `mapstructure:"id"`
+    Regex    *string  `mapstructure:"regex"`
+    MinLen   *int     `mapstructure:"min_len"`
+    MaxLen   *int     `mapstructure:"max_len"`
+    MinValue *float64 `mapstructure:"min_value"`
+    MaxValue *float64 `mapstructure:"max_value"`
+    Split    *string  `mapstructure:"split"`
+    Decimal  *string  `mapstructure:"decimal"`
+    FillDown *bool    `mapstructure:"fill_down"`
+    Priority *int     `mapstructure:"priority"`
+    Skip     bool     `mapstructure:"skip"`
+}
+
+// override is an Override of the workbook with a compiled sheet pattern.
+type override struct {
+    Override
+    sheet *regexp.Regexp
+}
+
+// workbookOverrides returns the overrides matching the workbook file name.
+func workbookOverrides(fpath string, overrides []Override) ([]override, error) {
+    var res []override
+    for i, o := range overrides {
+        if o.Workbook != "" {
+            ok, err := filepath.Match(o.Workbook, filepath.Base(fpath))
+            if err != nil {
+                return nil, fmt.Errorf("%w %d `%s`: %v", ErrOverridePattern, i, o.Workbook, err)
+            }
+            if !ok {
+                continue
+            }
+        }
+
+        re, err := regexp.Compile(o.Sheet)
+        if err != nil {
+            return nil, fmt.Errorf("%w %d `%s`: %v", ErrOverridePattern, i, o.Sheet, err)
+        }
+        res = append(res, override{Override: o, sheet: re})
+    }
+
+    return res, nil
+}
+
+// initSheetElements resolves the effective elements, regexes and header row
+// of the worksheet. It reports whether the worksheet is skipped.
+func (wb *Workbook) initSheetElements(ws *Worksheet) (bool, error) {
+    ws.Elements = wb.HazopElements
+    ws.regexps = wb.regexps
+    ws.splits = wb.splits
+
+    var matched []override
+    for _, o := range wb.overrides {
+        if o.sheet.MatchString(ws.Name) {
+            matched = append(matched, o)
+        }
+    }
+
+    if len(matched) == 0 {
+        return false, nil
+    }
+
+    elements := make(map[int]HazopElement, len(wb.HazopElements))
+    regexps := make(map[int]*regexp.Regexp, len(wb.regexps))
+    splits := make(map[int]*regexp.Regexp, len(wb.splits))
+    for k, e := range wb.HazopElements {
+        elements[k] = e
+        regexps[k] = wb.regexps[k]
+        if split, ok := wb.splits[k]; ok {
+            splits[k] = split
+        }
+    }
+
+    skip := false
+    for _, o := range matched {
+        skip = skip || o.Skip
+        if o.HeaderRow > 0 {
+            ws.forcedHeaderRow = o.HeaderRow
+        }
+
+        for _, eo := range o.Elements {
+            e, ok := elements[eo.Id]
+            if !ok {
+                continue
+            }
+
+            if eo.Skip {
+                delete(elements, eo.Id)
+                delete(regexps, eo.Id)
+                delete(splits, eo.Id)
+                continue
+            }
+
+            if eo.Regex != nil {
+                re, err := regexp.Compile(*eo.Regex)
+                if err != nil {
+                    return false, fmt.Errorf("%w `%d:%s`: %v", ErrHeaderRegex, e.Id, e.Name, err)
+                }
+                e.Regex = *eo.Regex
+                regexps[eo.Id] = re
+            }
+            if eo.Split != nil {
+                e.Split = *eo.Split
+                split, err := compileSplit(e)
+                if err != nil {
+                    return false, err
+                }
+                delete(splits, eo.Id)
+                if split != nil {
+                    splits[eo.Id] = split
+                }
+            }
+            if eo.MinLen != nil {
+                e.MinLen = *eo.MinLen
+            }
+            if eo.MaxLen != nil {
+                e.MaxLen = *eo.MaxLen
+            }
+            if eo.MinValue != nil {
+                e.MinValue = eo.MinValue
+            }
+            if eo.MaxValue != nil {
+                e.MaxValue = eo.MaxValue
+            }
+            if eo.Decimal != nil {
+                e.Decimal = *eo.Decimal
+            }
+            if eo.FillDown != nil {
+                e.FillDown = *eo.FillDown
+            }
+            if eo.Priority != nil {
+                e.Priority = *eo.Priority
+            }
+            elements[eo.Id] = e
+        }
+
+        ws.diagnose(Diagnostic{
+            Code:     CodeSheetOverride,
+            Severity: SeverityInfo,
+            Message:  fmt.Sprintf("%s `%s` `%s`", InfoSheetOverride, o.Workbook, o.Sheet),
+        })
+    }
+
+    ws.Elements = elements
+    ws.regexps = regexps
+    ws.splits = splits
+
+    return skip, nil
+}
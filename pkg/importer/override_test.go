@@ -0,0 +1,161 @@
+package importer
+
+import (
+    "context"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/spf13/viper"
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+// writeOverrideWorkbook writes a workbook with a metadata sheet and an
+// analysis sheet whose header row is below a row of matching labels.
+func writeOverrideWorkbook(fpath string) error {
+    f := excelize.NewFile()
+    f.SetSheetName("Sheet1", "Node1-Metadata")
+    f.SetSheetRow("Node1-Metadata", "A1", &[]interface{}{"Deviation", "Cause"})
+    f.SetSheetRow("Node1-Metadata", "A2", &[]interface{}{"Author", "Date"})
+
+    f.NewSheet("Node1-Analysis")
+    f.SetSheetRow("Node1-Analysis", "A1", &[]interface{}{"Deviation", "Cause", "Notes"})
+    f.SetSheetRow("Node1-Analysis", "A2", &[]interface{}{"Deviation", "Cause", "Existing controls"})
+    f.SetSheetRow("Node1-Analysis", "A3", &[]interface{}{"No flow", "Pump fails", "Pump alarm"})
+    f.SetSheetRow("Node1-Analysis", "A4", &[]interface{}{"More flow", "Valve fails open", "Relief valve"})
+
+    return f.SaveAs(fpath)
+}
+
+func TestOverrides(t *testing.T) {
+    assert := assert.New(t)
+
+    regex := "^(?i)(existing\\s?controls?)"
+    maxLen := 10
+    hazop := &HazopElements{Elements: []HazopElement{
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", DataType: "string", MaxLen: 80},
+        {Id: 6, Name: "Cause", Regex: "^(?i)(cause)", DataType: "string", MaxLen: 160},
+        {Id: 7, Name: "Consequence", Regex: "^(?i)(consequence)", DataType: "string", MaxLen: 160},
+        {Id: 8, Name: "Safeguard", Regex: "^(?i)(safeguard)", DataType: "string", MaxLen: 160, Split: "list"},
+    }}
+    hazop.Overrides = []Override{
+        {Workbook: "*.ods", Sheet: "(?i)analysis$", Skip: true},
+        {Sheet: "(?i)metadata$", Skip: true},
+        {
+            Workbook:  "override*.xlsx",
+            Sheet:     "(?i)analysis$",
+            HeaderRow: 2,
+            Elements: []ElementOverride{
+                {Id: 8, Regex: &regex},
+                {Id: 6, MaxLen: &maxLen},
+                {Id: 7, Skip: true},
+            },
+        },
+    }
+
+    fpath := filepath.Join(t.TempDir(), "override.xlsx")
+    assert.Empty(writeOverrideWorkbook(fpath))
+
+    wb, err := ImportWorkbookContext(context.Background(), fpath, Options{Hazop: hazop})
+    assert.Empty(err)
+    assert.Len(wb.Worksheets, 1)
+
+    ws := wb.Worksheets[0]
+    assert.Equal("Node1-Analysis", ws.Name)
+    assert.Equal(2, ws.HeaderRow)
+    assert.Equal(map[int]string{5: "A2", 6: "B2", 8: "C2"}, ws.Headers)
+    assert.NotContains(ws.Elements, 7)
+    assert.Equal(regex, ws.Elements[8].Regex)
+    assert.Equal(10, ws.Elements[6].MaxLen)
+    assert.Equal(160, wb.HazopElements[6].MaxLen)
+
+    assert.Equal(2, ws.GraphNRows)
+    assert.Equal(Items{"Pump alarm"}, ws.Graph[0]["Safeguard"])
+    assert.Empty(ws.Graph[1]["Cause"])
+    assert.Contains(ws.Report.Info(), InfoSheetOverride+" `override*.xlsx` `(?i)analysis$`")
+}
+
+func TestOverridesHeaderRowNoMatch(t *testing.T) {
+    assert := assert.New(t)
+
+    hazop := &HazopElements{Elements: []HazopElement{
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", DataType: "string", MaxLen: 80},
+        {Id: 6, Name: "Cause", Regex: "^(?i)(cause)", DataType: "string", MaxLen: 160},
+    }}
+    hazop.Overrides = []Override{
+        {Sheet: "(?i)metadata$", Skip: true},
+        {Sheet: "(?i)analysis$", HeaderRow: 3},
+    }
+
+    fpath := filepath.Join(t.TempDir(), "override.xlsx")
+    assert.Empty(writeOverrideWorkbook(fpath))
+
+    wb, err := ImportWorkbookContext(context.Background(), fpath, Options{Hazop: hazop})
+    assert.Empty(err)
+    assert.Len(wb.Worksheets, 1)
+
+    ws := wb.Worksheets[0]
+    assert.False(ws.IsValid)
+    assert.Equal(0, ws.HeaderRow)
+    assert.Empty(ws.Headers)
+    assert.Empty(ws.Graph)
+    assert.Len(ws.Report.Code(CodeNoHeader), 1)
+    assert.Len(ws.Report.Code(CodeHeaderNotFound), 2)
+}
+
+func TestOverridesWithoutMatch(t *testing.T) {
+    assert := assert.New(t)
+
+    overrides := Hazop.Overrides
+    defer func() { Hazop.Overrides = overrides }()
+    Hazop.Overrides = []Override{{Workbook: "other.xlsx", Skip: true}}
+
+    fpath := filepath.Join(t.TempDir(), "override.xlsx")
+    assert.Empty(writeOverrideWorkbook(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+    assert.Len(wb.Worksheets, 2)
+    for _, ws := range wb.Worksheets {
+        assert.Equal(wb.HazopElements, ws.Elements)
+    }
+
+    Hazop.Overrides = []Override{{Sheet: "("}}
+    wb, err = ImportWorkbook(fpath)
+    assert.Error(err)
+    assert.Empty(wb)
+}
+
+func TestOverridesManifest(t *testing.T) {
+    assert := assert.New(t)
+
+    v := viper.New()
+    v.SetConfigType("toml")
+    assert.Empty(v.ReadConfig(strings.NewReader(`
+[hazop]
+elements = []
+
+[[hazop.overrides]]
+    workbook = "*.xlsx"
+    sheet = "(?i)analysis$"
+    header_row = 3
+    elements = [
+        { id = 8, regex = "^(?i)(controls)", split = "lines", fill_down = true },
+        { id = 14, skip = true },
+    ]
+`)))
+
+    var h HazopElements
+    assert.Empty(v.UnmarshalKey("hazop", &h))
+    assert.Len(h.Overrides, 1)
+
+    o := h.Overrides[0]
+    assert.Equal(3, o.HeaderRow)
+    assert.Len(o.Elements, 2)
+    assert.Equal("^(?i)(controls)", *o.Elements[0].Regex)
+    assert.Equal("lines", *o.Elements[0].Split)
+    assert.True(*o.Elements[0].FillDown)
+    assert.Nil(o.Elements[0].MinLen)
+    assert.True(o.Elements[1].Skip)
+}
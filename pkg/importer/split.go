@@ -0,0 +1,111 @@
+package importer
+
+import (
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+var (
+    ErrSplitPattern = errors.New("Error compiling split pattern")
+)
+
+// Items are the valid values of a cell split by the split pattern of its
+// element.
+type Items []interface{}
+
+// splitPatterns are the named split patterns, other patterns are regexes.
+var splitPatterns = map[string]string{
+    "lines":      `\r?\n`,
+    "semicolons": `;`,
+    "bullets":    `\s*[•·▪●◦]\s*|(?m)^\s*[-*]\s+`,
+    "numbering":  `(?:^|\s)\(?\d{1,2}[.)]\s+`,
+    "list":       `\r?\n|;|\s*[•·▪●◦]\s*|(?m)^\s*[-*]\s+|(?:^|\s)\(?\d{1,2}[.)]\s+`,
+}
+
+// compileSplit returns the split pattern of the element, nil without one.
+func compileSplit(e HazopElement) (*regexp.Regexp, error) {
+    if e.Split == "" {
+        return nil, nil
+    }
+
+    pattern, ok := splitPatterns[e.Split]
+    if !ok {
+        pattern = e.Split
+    }
+
+    re, err := regexp.Compile(pattern)
+    if err != nil {
+        return nil, fmt.Errorf("%w `%d:%s`: %v", ErrSplitPattern, e.Id, e.Name, err)
+    }
+    return re, nil
+}
+
+// splitCell returns the trimmed non-empty items of the value.
+func splitCell(re *regexp.Regexp, value string) []string {
+    var items []string
+    for _, item := range re.Split(value, -1) {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
+// testCell parses the value and verifies it against the element bounds.
+func testCell(t Tester, e HazopElement, value string) (interface{}, error) {
+    v, err := t.TestCellType(value)
+    if err != nil {
+        return nil, err
+    }
+
+    if lt, ok := t.(textLengthTester); ok {
+        err = lt.testTextLength(value, v, e.MinLen, e.MaxLen)
+    } else {
+        err = t.TestCellLength(v, e.MinLen, e.MaxLen)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return v, nil
+}
+
+// testHazopCell tests the value of the element, with a split pattern every
+// item on its own. The valid items are returned even if others fail, a value
+// without items is tested as is.
+func testHazopCell(t Tester, e HazopElement, re *regexp.Regexp, value string) (interface{}, []error) {
+    var items []string
+    if re != nil {
+        items = splitCell(re, value)
+    }
+
+    if len(items) == 0 {
+        v, err := testCell(t, e, value)
+        if err != nil {
+            return nil, []error{err}
+        }
+        if re != nil {
+            return Items{v}, nil
+        }
+        return v, nil
+    }
+
+    var (
+        valid Items
+        errs  []error
+    )
+    for i, item := range items {
+        v, err := testCell(t, e, item)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("%w item %d", err, i+1))
+            continue
+        }
+        valid = append(valid, v)
+    }
+
+    if len(valid) == 0 {
+        return nil, errs
+    }
+    return valid, errs
+}
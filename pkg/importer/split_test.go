@@ -0,0 +1,96 @@
+package importer
+
+import (
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/xuri/excelize/v2"
+)
+
+func TestSplitCell(t *testing.T) {
+    for _, tc := range []struct {
+        split string
+        value string
+        want  []string
+    }{
+        {"lines", "PSV-101\nHigh level alarm LAH-12\r\n\n", []string{"PSV-101", "High level alarm LAH-12"}},
+        {"semicolons", "PSV-101; LAH-12 ;", []string{"PSV-101", "LAH-12"}},
+        {"bullets", "• PSV-101 • High level alarm", []string{"PSV-101", "High level alarm"}},
+        {"bullets", "- PSV-101\n- LAH-12", []string{"PSV-101", "LAH-12"}},
+        {"numbering", "1. PSV-101 2. High level alarm LAH-12", []string{"PSV-101", "High level alarm LAH-12"}},
+        {"numbering", "1) Pump trip (2) Operator action", []string{"Pump trip", "Operator action"}},
+        {"numbering", "Relief valve sized for 2.5 bar", []string{"Relief valve sized for 2.5 bar"}},
+        {"list", "1. PSV-101\n2. LAH-12; operator rounds", []string{"PSV-101", "LAH-12", "operator rounds"}},
+        {`\s+and\s+`, "PSV-101 and LAH-12", []string{"PSV-101", "LAH-12"}},
+        {"lines", " \n ", nil},
+    } {
+        re, err := compileSplit(HazopElement{Split: tc.split})
+        assert.Empty(t, err)
+        assert.Equal(t, tc.want, splitCell(re, tc.value), tc.value)
+    }
+
+    re, err := compileSplit(HazopElement{})
+    assert.Empty(t, err)
+    assert.Nil(t, re)
+
+    _, err = compileSplit(HazopElement{Id: 8, Name: "Safeguard", Split: "("})
+    assert.Error(t, err)
+}
+
+func TestTestHazopCell(t *testing.T) {
+    assert := assert.New(t)
+
+    e := HazopElement{Name: "Safeguard", MinLen: 1, MaxLen: 10, Split: "semicolons"}
+    tester, err := NewTester(e)
+    assert.Empty(err)
+    re, err := compileSplit(e)
+    assert.Empty(err)
+
+    v, errs := testHazopCell(tester, e, re, "PSV-101; LAH-12")
+    assert.Empty(errs)
+    assert.Equal(Items{"PSV-101", "LAH-12"}, v)
+
+    v, errs = testHazopCell(tester, e, re, "PSV-101; High level alarm")
+    assert.Len(errs, 1)
+    assert.EqualError(errs[0], ErrValueOutOfRange.Error()+" 1-10 item 2")
+    assert.Equal(Items{"PSV-101"}, v)
+
+    v, errs = testHazopCell(tester, e, re, "")
+    assert.Len(errs, 1)
+    assert.Nil(v)
+
+    v, errs = testHazopCell(tester, e, nil, "PSV-101")
+    assert.Empty(errs)
+    assert.Equal("PSV-101", v)
+}
+
+func TestImportSplit(t *testing.T) {
+    assert := assert.New(t)
+
+    elements := Hazop.Elements
+    defer func() { Hazop.Elements = elements }()
+    Hazop.Elements = []HazopElement{
+        {Id: 5, Name: "Deviation", Regex: "^(?i)(deviation)", MinLen: 1, MaxLen: 80},
+        {Id: 8, Name: "Safeguard", Regex: "^(?i)(safeguard)", MinLen: 1, MaxLen: 30, Split: "list"},
+    }
+
+    f := excelize.NewFile()
+    f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Deviation", "Safeguard"})
+    f.SetSheetRow("Sheet1", "A2", &[]interface{}{"More flow", "1. PSV-101 2. High level alarm LAH-12"})
+    f.SetSheetRow("Sheet1", "A3", &[]interface{}{"Less flow", "PSV-101"})
+    f.SetSheetRow("Sheet1", "A4", &[]interface{}{"No flow", "Low flow alarm FAL-3\nPressure relief valve PSV-101 on the outlet"})
+
+    fpath := filepath.Join(t.TempDir(), "split.xlsx")
+    assert.Empty(f.SaveAs(fpath))
+
+    wb, err := ImportWorkbook(fpath)
+    assert.Empty(err)
+
+    ws := wb.Worksheets[0]
+    assert.Equal(Items{"PSV-101", "High level alarm LAH-12"}, ws.Graph[0]["Safeguard"])
+    assert.Equal(Items{"PSV-101"}, ws.Graph[1]["Safeguard"])
+    assert.Equal(Items{"Low flow alarm FAL-3"}, ws.Graph[2]["Safeguard"])
+    assert.Contains(ws.Report.Errors(), ErrValueOutOfRange.Error()+" 1-30 item 2 `B4`")
+    assert.Equal(5, ws.NValidCells)
+}
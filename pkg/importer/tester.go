@@ -1,80 +1,335 @@
 package importer
 
 import (
+    "errors"
     "fmt"
+    "math"
+    "regexp"
+    "sort"
     "strconv"
+    "strings"
+    "sync"
 )
 
 var (
-    ErrParsingInteger  = "Error parsing integer"
-    ErrParsingFloat    = "Error parsing float"
-    ErrValueOutOfRange = "Error value out of range"
-    ErrUnknownDatatype = "Error unknown data type"
+    ErrParsingInteger  = errors.New("Error parsing integer")
+    ErrParsingFloat    = errors.New("Error parsing float")
+    ErrDecimalSep      = errors.New("Error unknown decimal separator")
+    ErrAmbiguousNumber = errors.New("Error ambiguous decimal separator")
+    ErrParsingBoolean  = errors.New("Error parsing boolean")
+    ErrParsingEnum     = errors.New("Error parsing enum value")
+    ErrParsingRefList  = errors.New("Error parsing reference list")
+    ErrValueOutOfRange = errors.New("Error value out of range")
+    ErrUnknownDatatype = errors.New("Error unknown data type")
 )
 
-type tester interface {
-    testCellType(interface{}) (interface{}, error)
-    testCellLength(interface{}, int, int) error
+// Data type names of the manifest data_type.
+const (
+    StringType        = "string"
+    IntegerType       = "integer"
+    FloatType         = "float"
+    DateType          = "date"
+    DateTimeType      = "datetime"
+    BooleanType       = "boolean"
+    EnumType          = "enum"
+    ReferenceListType = "reference-list"
+)
+
+// legacyTypes maps the former numeric data types to their names.
+var legacyTypes = map[string]string{
+    "":  StringType,
+    "0": StringType,
+    "1": IntegerType,
+    "2": FloatType,
+}
+
+// Tester parses and verifies the cell values of a hazop element.
+type Tester interface {
+    // TestCellType parses the cell value.
+    TestCellType(value string) (interface{}, error)
+    // TestCellLength verifies the parsed value against the min_len and
+    // max_len of the element.
+    TestCellLength(value interface{}, min, max int) error
+}
+
+// textLengthTester is a tester verifying min_len and max_len against the cell
+// text as written instead of the parsed value.
+type textLengthTester interface {
+    testTextLength(text string, value interface{}, min, max int) error
+}
+
+// TesterFactory returns the tester of a hazop element.
+type TesterFactory func(e HazopElement) (Tester, error)
+
+var (
+    testersMu sync.RWMutex
+    testers   = map[string]TesterFactory{
+        StringType:        func(e HazopElement) (Tester, error) { return testString{}, nil },
+        IntegerType:       func(e HazopElement) (Tester, error) { return testInteger(newTestNumber(e)), nil },
+        FloatType:         func(e HazopElement) (Tester, error) { return testFloat(newTestNumber(e)), nil },
+        DateType:          newTestDate,
+        DateTimeType:      newTestDate,
+        BooleanType:       func(e HazopElement) (Tester, error) { return testBoolean{}, nil },
+        EnumType:          newTestEnum,
+        ReferenceListType: func(e HazopElement) (Tester, error) { return testRefList{}, nil },
+    }
+)
+
+// RegisterTester registers the tester factory of a data type name, a
+// registered name is replaced. Register testers before importing workbooks.
+func RegisterTester(name string, f TesterFactory) {
+    testersMu.Lock()
+    defer testersMu.Unlock()
+    testers[name] = f
+}
+
+// DataTypes returns the registered data type names in ascending order.
+func DataTypes() []string {
+    testersMu.RLock()
+    defer testersMu.RUnlock()
+
+    names := make([]string, 0, len(testers))
+    for name := range testers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// NewTester returns the tester registered for the data type of the element.
+func NewTester(e HazopElement) (Tester, error) {
+    testersMu.RLock()
+    f, ok := testers[e.Type()]
+    testersMu.RUnlock()
+
+    if !ok {
+        return nil, fmt.Errorf("%w: `%s`", ErrUnknownDatatype, e.DataType)
+    }
+    return f(e)
 }
 
 type testString struct{}
-type testFloat struct{}
-type testInteger struct{}
-
-// 0 - STRING, 1 - INTEGER, 2 - FLOAT
-func newTester(dataType int) (tester, error) {
-    switch dataType {
-    case 0:
-        return testString{}, nil
-    case 1:
-        return testInteger{}, nil
-    case 2:
-        return testFloat{}, nil
+type testFloat testNumber
+type testInteger testNumber
+type testBoolean struct{}
+type testRefList struct{}
+
+// testEnum accepts the terms and synonyms of the vocabulary and the values
+// of the element, values are normalized to the canonical term.
+type testEnum struct {
+    vocabulary *Vocabulary
+}
+
+func newTestEnum(e HazopElement) (Tester, error) {
+    v := NewVocabulary()
+    if e.Vocabulary != "" {
+        o, err := ReadVocabulary(e.Vocabulary)
+        if err != nil {
+            return nil, err
+        }
+        v.Merge(o)
+    }
+
+    for _, line := range e.Values {
+        if err := v.AddLine(line); err != nil {
+            return nil, fmt.Errorf("%v `%d:%s`", err, e.Id, e.Name)
+        }
+    }
+
+    if v.Len() == 0 {
+        return nil, fmt.Errorf("%w: `%s` without values", ErrUnknownDatatype, e.DataType)
+    }
+    return testEnum{vocabulary: v}, nil
+}
+
+// testNumber parses numbers with the decimal separator of the element and
+// bounds them by min_value and max_value, nil bounds are open.
+type testNumber struct {
+    decimal  string
+    minValue *float64
+    maxValue *float64
+}
+
+func newTestNumber(e HazopElement) testNumber {
+    return testNumber{decimal: e.Decimal, minValue: e.MinValue, maxValue: e.MaxValue}
+}
+
+// groupSeps are the digit grouping characters besides `.` and `,`.
+var groupSeps = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "'", "")
+
+// ambiguousSep matches numbers whose single `,` or `.` is a decimal separator
+// or groups thousands, e.g. `1,234` or `1.234`.
+var ambiguousSep = regexp.MustCompile(`^[+-]?[1-9][0-9]{0,2}[.,][0-9]{3}$`)
+
+// parseNumber parses a decimal number, e.g. `1,234.5`, `1.234,5`, `12 %` or
+// `1.5e-3`. Percentages are divided by 100. With an empty decimal separator
+// the last of `.` and `,` is the decimal separator, a single `,` alone too.
+// A single `.` or `,` followed by exactly three digits after at most three
+// digits is ambiguous, e.g. `1,234` or `1.234`.
+func parseNumber(value, decimal string) (float64, error) {
+    s := strings.TrimSpace(value)
+    scale := 1.0
+    if strings.HasSuffix(s, "%") {
+        s = strings.TrimSuffix(s, "%")
+        scale = 0.01
+    }
+    s = groupSeps.Replace(s)
+
+    if decimal == "" {
+        if ambiguousSep.MatchString(s) {
+            return 0, ErrAmbiguousNumber
+        }
+        decimal = "."
+        dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+        if comma > dot && (dot >= 0 || strings.Count(s, ",") == 1) {
+            decimal = ","
+        }
+    }
+
+    switch decimal {
+    case ",":
+        s = strings.Replace(strings.Replace(s, ".", "", -1), ",", ".", 1)
+    case ".":
+        s = strings.Replace(s, ",", "", -1)
     default:
-        return nil, fmt.Errorf("%s: %d", ErrUnknownDatatype, dataType)
+        return 0, fmt.Errorf("%w `%s`", ErrDecimalSep, decimal)
+    }
+
+    v, err := strconv.ParseFloat(s, 64)
+    if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+        return 0, ErrParsingFloat
     }
+    return v * scale, nil
 }
 
-func (v testString) testCellType(value interface{}) (interface{}, error) {
-    return value.(string), nil
+// testBounds verifies the length of the number as written and its value.
+func (c testNumber) testBounds(text string, v float64, min, max int) error {
+    if len(text) < min || len(text) > max {
+        return fmt.Errorf("%w %d-%d", ErrValueOutOfRange, min, max)
+    }
+
+    if (c.minValue != nil && v < *c.minValue) || (c.maxValue != nil && v > *c.maxValue) {
+        return fmt.Errorf("%w %s-%s", ErrValueOutOfRange, formatBound(c.minValue), formatBound(c.maxValue))
+    }
+    return nil
 }
 
-func (c testInteger) testCellType(value interface{}) (interface{}, error) {
-    if v, err := strconv.Atoi(value.(string)); err != nil {
-        return nil, fmt.Errorf(ErrParsingInteger)
-    } else {
+// formatBound formats a value bound, open bounds are empty.
+func formatBound(b *float64) string {
+    if b == nil {
+        return ""
+    }
+    return strconv.FormatFloat(*b, 'f', -1, 64)
+}
+
+// booleans maps the accepted boolean cell values in lower case.
+var booleans = map[string]bool{
+    "true": true, "yes": true, "y": true, "x": true, "1": true,
+    "false": false, "no": false, "n": false, "0": false,
+}
+
+// reference is a single entry of a reference list, e.g. `1.2` or `A-3`.
+var reference = regexp.MustCompile(`^[\pL\pN][\pL\pN./-]*$`)
+
+// referenceSep splits a reference list.
+var referenceSep = regexp.MustCompile(`[,;\n]+`)
+
+func (v testString) TestCellType(value string) (interface{}, error) {
+    return value, nil
+}
+
+func (c testInteger) TestCellType(value string) (interface{}, error) {
+    if v, err := strconv.Atoi(value); err == nil {
         return v, nil
     }
+
+    v, err := parseNumber(value, c.decimal)
+    if errors.Is(err, ErrAmbiguousNumber) {
+        return nil, err
+    }
+    if err != nil || v != math.Trunc(v) || math.Abs(v) > math.MaxInt32 {
+        return nil, ErrParsingInteger
+    }
+    return int(v), nil
 }
 
-func (c testFloat) testCellType(value interface{}) (interface{}, error) {
-    if v, err := strconv.ParseFloat(value.(string), 32); err != nil {
-        return nil, fmt.Errorf(ErrParsingFloat)
-    } else {
+func (c testFloat) TestCellType(value string) (interface{}, error) {
+    return parseNumber(value, c.decimal)
+}
+
+func (c testBoolean) TestCellType(value string) (interface{}, error) {
+    if v, ok := booleans[strings.ToLower(strings.TrimSpace(value))]; ok {
         return v, nil
     }
+    return nil, ErrParsingBoolean
 }
 
-func (c testString) testCellLength(value interface{}, min, max int) error {
-    if len(value.(string)) < min || len(value.(string)) > max {
-        return fmt.Errorf("%s %d-%d", ErrValueOutOfRange, min, max)
-    } else {
-        return nil
+func (c testEnum) TestCellType(value string) (interface{}, error) {
+    if v, ok := c.vocabulary.Term(value); ok {
+        return v, nil
     }
+    return nil, fmt.Errorf("%w `%s` closest `%s`", ErrParsingEnum, value, c.vocabulary.Suggest(value))
 }
 
-func (c testInteger) testCellLength(value interface{}, min, max int) error {
-    if value.(int) < min || value.(int) > max {
-        return fmt.Errorf("%s %d-%d", ErrValueOutOfRange, min, max)
-    } else {
-        return nil
+func (c testRefList) TestCellType(value string) (interface{}, error) {
+    var refs []string
+    for _, r := range referenceSep.Split(value, -1) {
+        r = strings.TrimSpace(r)
+        if r == "" {
+            continue
+        }
+        if !reference.MatchString(r) {
+            return nil, ErrParsingRefList
+        }
+        refs = append(refs, r)
     }
+
+    if len(refs) == 0 {
+        return nil, ErrParsingRefList
+    }
+    return refs, nil
 }
 
-func (c testFloat) testCellLength(value interface{}, min, max int) error {
-    if value.(float32) < float32(min) || value.(float32) > float32(max) {
-        return fmt.Errorf("%s %d-%d", ErrValueOutOfRange, min, max)
+func (c testString) TestCellLength(value interface{}, min, max int) error {
+    if len(value.(string)) < min || len(value.(string)) > max {
+        return fmt.Errorf("%w %d-%d", ErrValueOutOfRange, min, max)
     } else {
         return nil
     }
 }
+
+// TestCellLength verifies the number of digits and the value of the integer.
+func (c testInteger) TestCellLength(value interface{}, min, max int) error {
+    v := value.(int)
+    return testNumber(c).testBounds(strconv.Itoa(v), float64(v), min, max)
+}
+
+// TestCellLength verifies the length of the shortest decimal notation and
+// the value of the float, cells are verified by testTextLength.
+func (c testFloat) TestCellLength(value interface{}, min, max int) error {
+    v := value.(float64)
+    return testNumber(c).testBounds(strconv.FormatFloat(v, 'f', -1, 64), v, min, max)
+}
+
+// testTextLength verifies the length of the float as written and its value.
+func (c testFloat) testTextLength(text string, value interface{}, min, max int) error {
+    return testNumber(c).testBounds(strings.TrimSpace(text), value.(float64), min, max)
+}
+
+// TestCellLength accepts both values.
+func (c testBoolean) TestCellLength(value interface{}, min, max int) error {
+    return nil
+}
+
+// TestCellLength accepts all values of the enum.
+func (c testEnum) TestCellLength(value interface{}, min, max int) error {
+    return nil
+}
+
+// TestCellLength verifies the number of references.
+func (c testRefList) TestCellLength(value interface{}, min, max int) error {
+    if n := len(value.([]string)); n < min || n > max {
+        return fmt.Errorf("%w %d-%d", ErrValueOutOfRange, min, max)
+    }
+    return nil
+}
@@ -1,8 +1,11 @@
 package importer
 
 import (
+    "fmt"
+    "strings"
     "testing"
 
+    "github.com/spf13/viper"
     "github.com/stretchr/testify/assert"
 )
 
@@ -11,26 +14,77 @@ func TestNewTester(tt *testing.T) {
 
     var (
         err error
-        t   tester
+        t   Tester
     )
 
-    t, err = newTester(0)
+    t, err = NewTester(HazopElement{DataType: "0"})
     assert.Empty(err)
     assert.Exactly(t, testString{})
 
-    t, err = newTester(1)
+    t, err = NewTester(HazopElement{DataType: "1"})
     assert.Empty(err)
     assert.Exactly(t, testInteger{})
 
-    t, err = newTester(2)
+    t, err = NewTester(HazopElement{DataType: "2"})
     assert.Empty(err)
     assert.Exactly(t, testFloat{})
 
-    t, err = newTester(5)
+    t, err = NewTester(HazopElement{})
+    assert.Empty(err)
+    assert.Exactly(t, testString{})
+
+    t, err = NewTester(HazopElement{DataType: "boolean"})
+    assert.Empty(err)
+    assert.Exactly(t, testBoolean{})
+
+    t, err = NewTester(HazopElement{DataType: "reference-list"})
+    assert.Empty(err)
+    assert.Exactly(t, testRefList{})
+
+    t, err = NewTester(HazopElement{DataType: "5"})
+    assert.Error(err)
+    assert.Empty(t)
+
+    t, err = NewTester(HazopElement{DataType: "enum"})
     assert.Error(err)
     assert.Empty(t)
 }
 
+// testUpper accepts upper case values only.
+type testUpper struct{ testString }
+
+func (c testUpper) TestCellType(value string) (interface{}, error) {
+    if strings.ToUpper(value) != value {
+        return nil, fmt.Errorf("not upper case")
+    }
+    return value, nil
+}
+
+func TestRegisterTester(tt *testing.T) {
+    assert := assert.New(tt)
+
+    defer func() {
+        testersMu.Lock()
+        delete(testers, "upper")
+        testersMu.Unlock()
+    }()
+
+    RegisterTester("upper", func(e HazopElement) (Tester, error) {
+        return testUpper{}, nil
+    })
+    assert.Contains(DataTypes(), "upper")
+
+    t, err := NewTester(HazopElement{DataType: "upper"})
+    assert.Empty(err)
+
+    val, err := t.TestCellType("P-101")
+    assert.Empty(err)
+    assert.Equal("P-101", val)
+
+    _, err = t.TestCellType("p-101")
+    assert.Error(err)
+}
+
 func TestTestString(tt *testing.T) {
     assert := assert.New(tt)
 
@@ -40,18 +94,18 @@ func TestTestString(tt *testing.T) {
         t   testString
     )
 
-    val, err = t.testCellType("")
+    val, err = t.TestCellType("")
     assert.Empty(err)
     assert.Empty(val)
 
-    val, err = t.testCellType("txt")
+    val, err = t.TestCellType("txt")
     assert.Empty(err)
     assert.Equal(val, "txt")
 
-    err = t.testCellLength("txt", 0, 4)
+    err = t.TestCellLength("txt", 0, 4)
     assert.Empty(err)
 
-    err = t.testCellLength("txt", 0, 0)
+    err = t.TestCellLength("txt", 0, 0)
     assert.Error(err)
 }
 
@@ -64,22 +118,22 @@ func TestTestInteger(tt *testing.T) {
         t   testInteger
     )
 
-    val, err = t.testCellType("text")
+    val, err = t.TestCellType("text")
     assert.Error(err)
     assert.Empty(val)
 
-    val, err = t.testCellType("0")
+    val, err = t.TestCellType("0")
     assert.Empty(err)
     assert.Empty(val)
 
-    val, err = t.testCellType("2")
+    val, err = t.TestCellType("2")
     assert.Empty(err)
     assert.Equal(val, 2)
 
-    err = t.testCellLength(2, 0, 4)
+    err = t.TestCellLength(2, 0, 4)
     assert.Empty(err)
 
-    err = t.testCellLength(2, 0, 0)
+    err = t.TestCellLength(2, 0, 0)
     assert.Error(err)
 }
 
@@ -92,21 +146,211 @@ func TestTestFloat(tt *testing.T) {
         t   testFloat
     )
 
-    val, err = t.testCellType("txt")
+    val, err = t.TestCellType("txt")
     assert.Error(err)
     assert.Empty(val)
 
-    val, err = t.testCellType("0")
+    val, err = t.TestCellType("0")
     assert.Empty(err)
     assert.Empty(val)
 
-    val, err = t.testCellType("2.0")
+    val, err = t.TestCellType("2.0")
     assert.Empty(err)
     assert.Equal(val, 2.0)
 
-    err = t.testCellLength(float32(2), 0, 4)
+    err = t.TestCellLength(2.0, 0, 4)
     assert.Empty(err)
 
-    err = t.testCellLength(float32(2), 0, 0)
+    err = t.TestCellLength(2.0, 0, 0)
     assert.Error(err)
 }
+
+func TestParseNumber(tt *testing.T) {
+    for _, tc := range []struct {
+        value   string
+        decimal string
+        want    float64
+        err     bool
+    }{
+        {value: "42", want: 42},
+        {value: " -3.5 ", want: -3.5},
+        {value: "0.25", want: 0.25},
+        {value: "1,5", want: 1.5},
+        {value: "1.234,5", want: 1234.5},
+        {value: "1,234.5", want: 1234.5},
+        {value: "1,234,567", want: 1234567},
+        {value: "1234,567", want: 1234.567},
+        {value: "0,125", want: 0.125},
+        {value: "1 234,5", want: 1234.5},
+        {value: "1\u00a0234,5", want: 1234.5},
+        {value: "1'234.5", want: 1234.5},
+        {value: "1,234", decimal: ".", want: 1234},
+        {value: "1.234", decimal: ",", want: 1234},
+        {value: "1,5", decimal: ",", want: 1.5},
+        {value: "12%", want: 0.12},
+        {value: "12,5 %", want: 0.125},
+        {value: "1.5e-3", want: 0.0015},
+        {value: "1,5E3", want: 1500},
+        {value: "2E+2", want: 200},
+        {value: "", err: true},
+        {value: "%", err: true},
+        {value: "1.5.5", err: true},
+        {value: "NaN", err: true},
+        {value: "Inf", err: true},
+        {value: "1,5", decimal: ";", err: true},
+        {value: "1,234", err: true},
+        {value: "1.234", err: true},
+        {value: "-1.500", err: true},
+        {value: "1.234", decimal: ".", want: 1.234},
+        {value: "1234.567", want: 1234.567},
+        {value: "-12,500 %", err: true},
+        {value: "approx. 3", err: true},
+    } {
+        v, err := parseNumber(tc.value, tc.decimal)
+        if tc.err {
+            assert.Error(tt, err, tc.value)
+            continue
+        }
+        assert.Empty(tt, err, tc.value)
+        assert.InDelta(tt, tc.want, v, 1e-9, tc.value)
+    }
+}
+
+func TestNumberBounds(tt *testing.T) {
+    one, five := 1.0, 5.0
+    for _, tc := range []struct {
+        name    string
+        element HazopElement
+        value   string
+        want    interface{}
+        err     string
+    }{
+        {name: "integer", element: HazopElement{DataType: "integer", MaxLen: 3}, value: "12", want: 12},
+        {name: "integer float notation", element: HazopElement{DataType: "integer", MaxLen: 3}, value: "12.0", want: 12},
+        {name: "integer exponent", element: HazopElement{DataType: "integer", MaxLen: 4}, value: "1e3", want: 1000},
+        {name: "integer grouping", element: HazopElement{DataType: "integer", MaxLen: 4, Decimal: ","}, value: "1.000", want: 1000},
+        {name: "integer fraction", element: HazopElement{DataType: "integer", MaxLen: 3}, value: "1.5", err: ErrParsingInteger.Error()},
+        {name: "integer too long", element: HazopElement{DataType: "integer", MaxLen: 3}, value: "1234", err: ErrValueOutOfRange.Error() + " 0-3"},
+        {name: "integer in range", element: HazopElement{DataType: "integer", MaxLen: 3, MinValue: &one, MaxValue: &five}, value: "5", want: 5},
+        {name: "integer below range", element: HazopElement{DataType: "integer", MaxLen: 3, MinValue: &one, MaxValue: &five}, value: "0", err: ErrValueOutOfRange.Error() + " 1-5"},
+        {name: "integer without max", element: HazopElement{DataType: "integer", MaxLen: 3, MinValue: &one}, value: "6", want: 6},
+        {name: "integer above max", element: HazopElement{DataType: "integer", MaxLen: 3, MaxValue: &five}, value: "6", err: ErrValueOutOfRange.Error() + " -5"},
+        {name: "float", element: HazopElement{DataType: "float", MaxLen: 8}, value: "0.25", want: 0.25},
+        {name: "float comma", element: HazopElement{DataType: "float", MaxLen: 8}, value: "0,25", want: 0.25},
+        {name: "float percent", element: HazopElement{DataType: "float", MaxLen: 8, MaxValue: &one}, value: "25 %", want: 0.25},
+        {name: "float exponent", element: HazopElement{DataType: "float", MaxLen: 8}, value: "2.5E-1", want: 0.25},
+        {name: "float in range", element: HazopElement{DataType: "float", MaxLen: 8, MinValue: &one, MaxValue: &five}, value: "4,5", want: 4.5},
+        {name: "float above range", element: HazopElement{DataType: "float", MaxLen: 8, MinValue: &one, MaxValue: &five}, value: "5.01", err: ErrValueOutOfRange.Error() + " 1-5"},
+        {name: "float percent above range", element: HazopElement{DataType: "float", MaxLen: 8, MaxValue: &one}, value: "150%", err: ErrValueOutOfRange.Error() + " -1"},
+        {name: "float too long", element: HazopElement{DataType: "float", MaxLen: 3}, value: "0.125", err: ErrValueOutOfRange.Error() + " 0-3"},
+        {name: "float length as written", element: HazopElement{DataType: "float", MaxLen: 3}, value: "1,50", err: ErrValueOutOfRange.Error() + " 0-3"},
+        {name: "float exponent as written", element: HazopElement{DataType: "float", MaxLen: 3}, value: "1e3", want: 1000.0},
+        {name: "float text", element: HazopElement{DataType: "float", MaxLen: 8}, value: "high", err: ErrParsingFloat.Error()},
+        {name: "float ambiguous comma", element: HazopElement{DataType: "float", MaxLen: 8}, value: "1,234", err: ErrAmbiguousNumber.Error()},
+        {name: "float decimal comma", element: HazopElement{DataType: "float", MaxLen: 8, Decimal: ","}, value: "1,234", want: 1.234},
+        {name: "float ambiguous dot", element: HazopElement{DataType: "float", MaxLen: 8}, value: "1.234", err: ErrAmbiguousNumber.Error()},
+        {name: "float decimal dot", element: HazopElement{DataType: "float", MaxLen: 8, Decimal: "."}, value: "1.234", want: 1.234},
+        {name: "integer ambiguous comma", element: HazopElement{DataType: "integer", MaxLen: 8}, value: "1,234", err: ErrAmbiguousNumber.Error()},
+        {name: "legacy float", element: HazopElement{DataType: "2", MinLen: 1, MaxLen: 100}, value: "3", want: 3.0},
+    } {
+        t, err := NewTester(tc.element)
+        assert.Empty(tt, err, tc.name)
+
+        v, err := testCell(t, tc.element, tc.value)
+
+        if tc.err != "" {
+            assert.EqualError(tt, err, tc.err, tc.name)
+            continue
+        }
+        assert.Empty(tt, err, tc.name)
+        assert.Equal(tt, tc.want, v, tc.name)
+    }
+}
+
+func TestTestBoolean(tt *testing.T) {
+    assert := assert.New(tt)
+
+    var t testBoolean
+    for value, want := range map[string]bool{"Yes": true, "x": true, "FALSE": false, " n ": false} {
+        val, err := t.TestCellType(value)
+        assert.Empty(err)
+        assert.Equal(want, val)
+    }
+
+    val, err := t.TestCellType("maybe")
+    assert.Error(err)
+    assert.Empty(val)
+}
+
+func TestTestEnum(tt *testing.T) {
+    assert := assert.New(tt)
+
+    t, err := NewTester(HazopElement{DataType: "enum", Values: []string{"Low", "Medium", "High"}})
+    assert.Empty(err)
+
+    val, err := t.TestCellType("high")
+    assert.Empty(err)
+    assert.Equal("High", val)
+
+    val, err = t.TestCellType("Critical")
+    assert.Error(err)
+    assert.Empty(val)
+
+    t, err = NewTester(HazopElement{DataType: "enum", Vocabulary: "guidewords", Values: []string{"More = too fast"}})
+    assert.Empty(err)
+
+    val, err = t.TestCellType("Too fast")
+    assert.Empty(err)
+    assert.Equal("More", val)
+
+    val, err = t.TestCellType("Mroe")
+    assert.EqualError(err, ErrParsingEnum.Error()+" `Mroe` closest `More`")
+    assert.Empty(val)
+
+    t, err = NewTester(HazopElement{DataType: "enum", Vocabulary: "missing"})
+    assert.Error(err)
+    assert.Empty(t)
+}
+
+func TestTestRefList(tt *testing.T) {
+    assert := assert.New(tt)
+
+    var t testRefList
+
+    val, err := t.TestCellType("1.1, 1.2;A-3\n4")
+    assert.Empty(err)
+    assert.Equal([]string{"1.1", "1.2", "A-3", "4"}, val)
+
+    err = t.TestCellLength(val, 1, 4)
+    assert.Empty(err)
+
+    err = t.TestCellLength(val, 1, 3)
+    assert.Error(err)
+
+    val, err = t.TestCellType("1.1, see below")
+    assert.Error(err)
+    assert.Empty(val)
+
+    val, err = t.TestCellType(" , ")
+    assert.Error(err)
+    assert.Empty(val)
+}
+
+func TestLegacyDataType(tt *testing.T) {
+    assert := assert.New(tt)
+
+    v := viper.New()
+    v.SetConfigType("toml")
+    assert.Empty(v.ReadConfig(strings.NewReader(`
+[hazop]
+elements = [
+    { id = 2, name = "Reference", data_type = 1 },
+    { id = 6, name = "Cause", data_type = "string" },
+]
+`)))
+
+    var h HazopElements
+    assert.Empty(v.UnmarshalKey("hazop", &h))
+    assert.Equal(IntegerType, h.Elements[0].Type())
+    assert.Equal(StringType, h.Elements[1].Type())
+}
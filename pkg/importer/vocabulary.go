@@ -0,0 +1,183 @@
+package importer
+
+import (
+    "bufio"
+    "embed"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "strings"
+    "sync"
+)
+
+var (
+    ErrReadingVocabulary = errors.New("Error reading vocabulary")
+    ErrVocabularyLine    = errors.New("Error vocabulary line")
+)
+
+// vocabularies are the vocabularies shipped with the tool, e.g. the IEC
+// 61882 guide words `guidewords`.
+//go:embed vocabularies/*.txt
+var vocabularies embed.FS
+
+var (
+    vocabularyMu    sync.Mutex
+    vocabularyCache = make(map[string]*Vocabulary)
+)
+
+// Vocabulary maps the normalized terms and their synonyms to the canonical
+// terms.
+type Vocabulary struct {
+    terms map[string]string
+}
+
+// NewVocabulary returns an empty vocabulary.
+func NewVocabulary() *Vocabulary {
+    return &Vocabulary{terms: make(map[string]string)}
+}
+
+// ReadVocabulary returns the shipped vocabulary of the name or reads the
+// vocabulary file under the path.
+func ReadVocabulary(name string) (*Vocabulary, error) {
+    vocabularyMu.Lock()
+    defer vocabularyMu.Unlock()
+
+    if v, ok := vocabularyCache[name]; ok {
+        return v, nil
+    }
+
+    f, err := vocabularies.Open("vocabularies/" + name + ".txt")
+    if err != nil {
+        f, err = os.Open(name)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w `%s`: %v", ErrReadingVocabulary, name, err)
+    }
+    defer f.Close()
+
+    v := NewVocabulary()
+    if err := v.Read(f); err != nil {
+        return nil, fmt.Errorf("%w `%s`: %v", ErrReadingVocabulary, name, err)
+    }
+    vocabularyCache[name] = v
+
+    return v, nil
+}
+
+// Read adds the lines of r, blank lines and lines starting with # are
+// skipped.
+func (v *Vocabulary) Read(r io.Reader) error {
+    s := bufio.NewScanner(r)
+    for n := 1; s.Scan(); n++ {
+        line := strings.TrimSpace(s.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        if err := v.AddLine(line); err != nil {
+            return fmt.Errorf("%v %d", err, n)
+        }
+    }
+    return s.Err()
+}
+
+// AddLine adds a line `Term = synonym, synonym`, the synonyms are optional.
+func (v *Vocabulary) AddLine(line string) error {
+    parts := strings.SplitN(line, "=", 2)
+    term := strings.TrimSpace(parts[0])
+    if term == "" {
+        return ErrVocabularyLine
+    }
+
+    if len(parts) == 2 {
+        v.Add(term, strings.Split(parts[1], ",")...)
+    } else {
+        v.Add(term)
+    }
+    return nil
+}
+
+// Add adds the canonical term and its synonyms, blank synonyms are skipped.
+func (v *Vocabulary) Add(term string, synonyms ...string) {
+    v.terms[normalizeTerm(term)] = term
+    for _, s := range synonyms {
+        if s := normalizeTerm(s); s != "" {
+            v.terms[s] = term
+        }
+    }
+}
+
+// Merge adds the terms of o.
+func (v *Vocabulary) Merge(o *Vocabulary) {
+    for k, term := range o.terms {
+        v.terms[k] = term
+    }
+}
+
+// Len returns the number of terms and synonyms.
+func (v *Vocabulary) Len() int {
+    return len(v.terms)
+}
+
+// Term returns the canonical term of the value, case, surrounding
+// punctuation and repeated spaces are ignored.
+func (v *Vocabulary) Term(value string) (string, bool) {
+    term, ok := v.terms[normalizeTerm(value)]
+    return term, ok
+}
+
+// Suggest returns the canonical term of the term or synonym closest to the
+// value by edit distance.
+func (v *Vocabulary) Suggest(value string) string {
+    keys := make([]string, 0, len(v.terms))
+    for k := range v.terms {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+
+    value = normalizeTerm(value)
+    best, dist := "", -1
+    for _, k := range keys {
+        if d := levenshtein(value, k); dist < 0 || d < dist {
+            best, dist = v.terms[k], d
+        }
+    }
+    return best
+}
+
+// normalizeTerm folds the case, trims punctuation and collapses spaces.
+func normalizeTerm(s string) string {
+    s = strings.Trim(strings.ToLower(s), " \t\r\n.,;:!?\"'")
+    return strings.Join(strings.Fields(s), " ")
+}
+
+// levenshtein returns the edit distance of a and b.
+func levenshtein(a, b string) int {
+    ra, rb := []rune(a), []rune(b)
+    prev := make([]int, len(rb)+1)
+    curr := make([]int, len(rb)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+
+    for i := 1; i <= len(ra); i++ {
+        curr[0] = i
+        for j := 1; j <= len(rb); j++ {
+            cost := 1
+            if ra[i-1] == rb[j-1] {
+                cost = 0
+            }
+            curr[j] = prev[j-1] + cost
+            if d := prev[j] + 1; d < curr[j] {
+                curr[j] = d
+            }
+            if d := curr[j-1] + 1; d < curr[j] {
+                curr[j] = d
+            }
+        }
+        prev, curr = curr, prev
+    }
+
+    return prev[len(rb)]
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestGuidewords(t *testing.T) {
+    assert := assert.New(t)
+
+    v, err := ReadVocabulary("guidewords")
+    assert.Empty(err)
+
+    for value, want := range map[string]string{
+        "no":          "No",
+        "None":        "No",
+        "HIGHER":      "More",
+        "Lower.":      "Less",
+        "as  well as": "As well as",
+        "Part of":     "Part of",
+        "back flow":   "Reverse",
+        "Other than":  "Other than",
+        "too early":   "Early",
+        "Late":        "Late",
+        "prior to":    "Before",
+        "After":       "After",
+    } {
+        term, ok := v.Term(value)
+        assert.True(ok, value)
+        assert.Equal(want, term, value)
+    }
+
+    _, ok := v.Term("Mroe")
+    assert.False(ok)
+    assert.Equal("More", v.Suggest("Mroe"))
+    assert.Equal("Reverse", v.Suggest("Reverce"))
+    assert.Equal("Other than", v.Suggest("otherthan"))
+}
+
+func TestReadVocabularyFile(t *testing.T) {
+    assert := assert.New(t)
+
+    fpath := filepath.Join(t.TempDir(), "actionon.txt")
+    assert.Empty(os.WriteFile(fpath, []byte("# action parties\nAW = A. Wright\n\nCD\n"), 0644))
+
+    v, err := ReadVocabulary(fpath)
+    assert.Empty(err)
+    assert.Equal(3, v.Len())
+
+    term, ok := v.Term("a. wright")
+    assert.True(ok)
+    assert.Equal("AW", term)
+
+    _, err = ReadVocabulary(filepath.Join(t.TempDir(), "missing.txt"))
+    assert.Error(err)
+
+    v = NewVocabulary()
+    err = v.Read(strings.NewReader("No\n = none\n"))
+    assert.EqualError(err, ErrVocabularyLine.Error()+" 2")
+}
+
+func TestLevenshtein(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal(0, levenshtein("more", "more"))
+    assert.Equal(2, levenshtein("mroe", "more"))
+    assert.Equal(1, levenshtein("reverce", "reverse"))
+    assert.Equal(4, levenshtein("", "late"))
+    assert.Equal(1, levenshtein("früh", "fruh"))
+}
@@ -0,0 +1,64 @@
+package rdf
+
+// Dataset is a default graph plus an ordered list of named graphs sharing
+// one set of prefixes.
+type Dataset struct {
+    Prefixes map[string]IRI
+    Default  *Graph
+    Names    []Term
+    graphs   map[Term]*Graph
+}
+
+func NewDataset() *Dataset {
+    return &Dataset{
+        Prefixes: make(map[string]IRI),
+        Default:  NewGraph(),
+        graphs:   make(map[Term]*Graph),
+    }
+}
+
+// Bind binds the prefix to the namespace ns for compact serializations.
+func (d *Dataset) Bind(prefix string, ns IRI) error {
+    if err := d.Default.Bind(prefix, ns); err != nil {
+        return err
+    }
+    d.Prefixes[prefix] = ns
+    return nil
+}
+
+// Graph returns the named graph name and creates it on first use.
+func (d *Dataset) Graph(name Term) *Graph {
+    if g, ok := d.graphs[name]; ok {
+        return g
+    }
+    g := NewGraph()
+    g.Prefixes = d.Prefixes
+    d.graphs[name] = g
+    d.Names = append(d.Names, name)
+    return g
+}
+
+// Union returns a graph of the default graph followed by all named graphs.
+func (d *Dataset) Union() *Graph {
+    u := NewGraph()
+    for p, ns := range d.Prefixes {
+        u.Prefixes[p] = ns
+    }
+    for _, t := range d.Default.Triples {
+        u.Add(t.Subject, t.Predicate, t.Object)
+    }
+    for _, name := range d.Names {
+        for _, t := range d.graphs[name].Triples {
+            u.Add(t.Subject, t.Predicate, t.Object)
+        }
+    }
+    return u
+}
+
+func (d *Dataset) Len() int {
+    n := d.Default.Len()
+    for _, g := range d.graphs {
+        n += g.Len()
+    }
+    return n
+}
@@ -0,0 +1,32 @@
+package rdf
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestDataset(t *testing.T) {
+    assert := assert.New(t)
+
+    d := NewDataset()
+    assert.Empty(d.Bind("ex", "http://x/"))
+    assert.Error(d.Bind("1ex", "http://x/"))
+
+    g1 := d.Graph(IRI("http://x/g1"))
+    g2 := d.Graph(IRI("http://x/g2"))
+    assert.Same(g1, d.Graph(IRI("http://x/g1")))
+    assert.Equal([]Term{IRI("http://x/g1"), IRI("http://x/g2")}, d.Names)
+    assert.Equal(IRI("http://x/"), g2.Prefixes["ex"])
+
+    d.Default.Add(IRI("http://x/g1"), RDFType, IRI("http://x/Sheet"))
+    g1.Add(IRI("http://x/s"), "http://x/p", NewLiteral("o"))
+    g2.Add(IRI("http://x/s"), "http://x/p", NewLiteral("o"))
+    g2.Add(IRI("http://x/s"), "http://x/p", NewLiteral("o2"))
+    assert.Equal(4, d.Len())
+
+    u := d.Union()
+    assert.Equal(3, u.Len())
+    assert.Equal(IRI("http://x/"), u.Prefixes["ex"])
+    assert.Equal(RDFType, u.Triples[0].Predicate)
+}
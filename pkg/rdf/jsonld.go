@@ -0,0 +1,283 @@
+package rdf
+
+import (
+    "bytes"
+    "encoding/json"
+    "io"
+    "sort"
+    "strings"
+)
+
+// IdType coerces the values of a context term to IRIs.
+const IdType = IRI("@id")
+
+// ContextTerm maps the JSON-LD term Name to the property Id. Literal values
+// of the given Type or Lang are written as plain JSON strings, IRI values
+// of a term of IdType as plain compact IRIs.
+type ContextTerm struct {
+    Name string
+    Id   IRI
+    Type IRI
+    Lang string
+}
+
+// Context is a JSON-LD @context of prefixes and property terms. Documents
+// reference a context with an IRI, the published context document, instead
+// of inlining it.
+type Context struct {
+    IRI      IRI
+    Prefixes map[string]IRI
+    Terms    []ContextTerm
+}
+
+// object is a JSON object which keeps the insertion order of its keys.
+type object struct {
+    keys []string
+    vals map[string]interface{}
+}
+
+func newObject() *object {
+    return &object{vals: make(map[string]interface{})}
+}
+
+func (o *object) set(key string, val interface{}) {
+    if _, ok := o.vals[key]; !ok {
+        o.keys = append(o.keys, key)
+    }
+    o.vals[key] = val
+}
+
+// add appends val to the values of key, a single value stays unwrapped.
+func (o *object) add(key string, val interface{}) {
+    switch v := o.vals[key].(type) {
+    case nil:
+        o.set(key, val)
+    case []interface{}:
+        o.vals[key] = append(v, val)
+    default:
+        o.vals[key] = []interface{}{v, val}
+    }
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+    var b bytes.Buffer
+    b.WriteString("{")
+    for i, k := range o.keys {
+        if i > 0 {
+            b.WriteString(",")
+        }
+        key, err := json.Marshal(k)
+        if err != nil {
+            return nil, err
+        }
+        val, err := json.Marshal(o.vals[k])
+        if err != nil {
+            return nil, err
+        }
+        b.Write(key)
+        b.WriteString(":")
+        b.Write(val)
+    }
+    b.WriteString("}")
+    return b.Bytes(), nil
+}
+
+// JSON returns the context as JSON-LD @context object.
+func (c *Context) JSON() interface{} {
+    ctx := newObject()
+
+    prefixes := make([]string, 0, len(c.Prefixes))
+    for p := range c.Prefixes {
+        prefixes = append(prefixes, p)
+    }
+    sort.Strings(prefixes)
+
+    for _, p := range prefixes {
+        ctx.set(p, string(c.Prefixes[p]))
+    }
+
+    for _, t := range c.Terms {
+        def := newObject()
+        def.set("@id", compactJSON(t.Id, c.Prefixes))
+        if t.Type == IdType {
+            def.set("@type", string(IdType))
+        } else if t.Type != "" {
+            def.set("@type", compactJSON(t.Type, c.Prefixes))
+        }
+        if t.Lang != "" {
+            def.set("@language", strings.ToLower(t.Lang))
+        }
+        ctx.set(t.Name, def)
+    }
+
+    return ctx
+}
+
+// WriteJSONLD writes the dataset d as compacted JSON-LD document with the
+// context c, inlined or referenced by its IRI. The default graph nodes are listed first, every named graph is
+// a node with its own @graph, merged with the default graph node of the
+// same @id if there is one.
+func WriteJSONLD(w io.Writer, d *Dataset, c *Context) error {
+    named := make(map[Term]bool, len(d.Names))
+    for _, name := range d.Names {
+        named[name] = true
+    }
+
+    var nodes []interface{}
+    seen := make(map[Term]bool)
+    for _, n := range c.nodes(d.Default) {
+        if named[n.subject] {
+            n.obj.set("@graph", c.objects(c.nodes(d.graphs[n.subject])))
+            seen[n.subject] = true
+        }
+        nodes = append(nodes, n.obj)
+    }
+
+    for _, name := range d.Names {
+        if seen[name] {
+            continue
+        }
+        obj := newObject()
+        obj.set("@id", c.id(name))
+        obj.set("@graph", c.objects(c.nodes(d.graphs[name])))
+        nodes = append(nodes, obj)
+    }
+
+    if nodes == nil {
+        nodes = []interface{}{}
+    }
+
+    doc := newObject()
+    if c.IRI != "" {
+        doc.set("@context", string(c.IRI))
+    } else {
+        doc.set("@context", c.JSON())
+    }
+    doc.set("@graph", nodes)
+
+    enc := json.NewEncoder(w)
+    enc.SetEscapeHTML(false)
+    enc.SetIndent("", "  ")
+    return enc.Encode(doc)
+}
+
+// WriteJSONLDContext writes the context c as JSON-LD context document, the
+// document published at the IRI of c.
+func WriteJSONLDContext(w io.Writer, c *Context) error {
+    doc := newObject()
+    doc.set("@context", c.JSON())
+
+    enc := json.NewEncoder(w)
+    enc.SetEscapeHTML(false)
+    enc.SetIndent("", "  ")
+    return enc.Encode(doc)
+}
+
+type node struct {
+    subject Term
+    obj     *object
+}
+
+// nodes groups the triples of g into node objects in subject order.
+func (c *Context) nodes(g *Graph) []node {
+    var nodes []node
+    index := make(map[Term]int)
+    for _, t := range g.Triples {
+        i, ok := index[t.Subject]
+        if !ok {
+            obj := newObject()
+            obj.set("@id", c.id(t.Subject))
+            i = len(nodes)
+            index[t.Subject] = i
+            nodes = append(nodes, node{subject: t.Subject, obj: obj})
+        }
+
+        obj := nodes[i].obj
+        if iri, ok := t.Object.(IRI); ok && t.Predicate == RDFType {
+            obj.add("@type", compactJSON(iri, c.Prefixes))
+            continue
+        }
+
+        key, val := c.property(t.Predicate, t.Object)
+        obj.add(key, val)
+    }
+    return nodes
+}
+
+func (c *Context) objects(nodes []node) []interface{} {
+    objs := make([]interface{}, len(nodes))
+    for i, n := range nodes {
+        objs[i] = n.obj
+    }
+    return objs
+}
+
+func (c *Context) id(t Term) string {
+    switch v := t.(type) {
+    case IRI:
+        return compactJSON(v, c.Prefixes)
+    case BlankNode:
+        return v.NTriples()
+    default:
+        return ""
+    }
+}
+
+// property returns the compacted key and value of the property p with the
+// object o. A term whose type or language matches the literal takes the
+// plain value, otherwise the value is written as value object.
+func (c *Context) property(p IRI, o Term) (string, interface{}) {
+    key := compactJSON(p, c.Prefixes)
+    var match *ContextTerm
+    for i, t := range c.Terms {
+        if t.Id != p {
+            continue
+        }
+        if match == nil {
+            key, match = t.Name, &c.Terms[i]
+        }
+        if lit, ok := o.(Literal); ok && t.Type == literalType(lit) && strings.EqualFold(t.Lang, lit.Lang) {
+            return t.Name, lit.Value
+        }
+        if _, ok := o.(Literal); !ok && t.Type == IdType {
+            return t.Name, c.id(o)
+        }
+    }
+
+    switch v := o.(type) {
+    case Literal:
+        if match == nil && v.Lang == "" && literalType(v) == "" {
+            return key, v.Value
+        }
+        val := newObject()
+        val.set("@value", v.Value)
+        if v.Lang != "" {
+            val.set("@language", v.Lang)
+        } else if dt := literalType(v); dt != "" {
+            val.set("@type", compactJSON(dt, c.Prefixes))
+        }
+        return key, val
+    default:
+        val := newObject()
+        val.set("@id", c.id(o))
+        return key, val
+    }
+}
+
+// literalType returns the datatype of lit if it is not implied by a plain
+// or language tagged string.
+func literalType(lit Literal) IRI {
+    if lit.Lang != "" || lit.Datatype == XSDString || lit.Datatype == RDFLangStr {
+        return ""
+    }
+    return lit.Datatype
+}
+
+// compactJSON returns the compact IRI of iri or iri itself.
+func compactJSON(iri IRI, prefixes map[string]IRI) string {
+    s := compact(iri, prefixes)
+    if strings.HasPrefix(s, "<") || strings.HasPrefix(s, ":") {
+        return string(iri)
+    }
+    return s
+}
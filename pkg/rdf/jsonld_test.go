@@ -0,0 +1,149 @@
+package rdf
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONLD(t *testing.T) {
+    assert := assert.New(t)
+
+    d := NewDataset()
+    d.Bind("ex", "http://x/")
+    d.Bind("xsd", XSD)
+
+    c := &Context{
+        Prefixes: d.Prefixes,
+        Terms: []ContextTerm{
+            {Name: "Reference", Id: "http://x/reference", Type: XSDInteger},
+            {Name: "Cause", Id: "http://x/cause", Lang: "en"},
+            {Name: "next", Id: "http://x/next", Type: IdType},
+        },
+    }
+
+    sheet := IRI("http://x/sheet")
+    d.Default.Add(sheet, RDFType, IRI("http://x/Sheet"))
+    d.Default.Add(sheet, "http://x/name", NewLiteral("Analysis"))
+
+    en, _ := NewLangLiteral("pump", "en")
+    de, _ := NewLangLiteral("Pumpe", "de")
+    g := d.Graph(sheet)
+    g.Add(IRI("http://x/row/1"), "http://x/reference", NewValueLiteral(1))
+    g.Add(IRI("http://x/row/1"), "http://x/cause", en)
+    g.Add(IRI("http://x/row/1"), "http://x/cause", de)
+    g.Add(IRI("http://x/row/2"), "http://x/reference", NewLiteral("A1"))
+    g.Add(IRI("http://x/row/2"), "http://x/next", BlankNode("b1"))
+    d.Graph(IRI("http://y/other")).Add(IRI("http://y/s"), "http://y/p", NewValueLiteral(0.5))
+
+    var b bytes.Buffer
+    assert.Empty(WriteJSONLD(&b, d, c))
+    assert.Equal(`{
+  "@context": {
+    "ex": "http://x/",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "Reference": {
+      "@id": "ex:reference",
+      "@type": "xsd:integer"
+    },
+    "Cause": {
+      "@id": "ex:cause",
+      "@language": "en"
+    },
+    "next": {
+      "@id": "ex:next",
+      "@type": "@id"
+    }
+  },
+  "@graph": [
+    {
+      "@id": "ex:sheet",
+      "@type": "ex:Sheet",
+      "ex:name": "Analysis",
+      "@graph": [
+        {
+          "@id": "http://x/row/1",
+          "Reference": "1",
+          "Cause": [
+            "pump",
+            {
+              "@value": "Pumpe",
+              "@language": "de"
+            }
+          ]
+        },
+        {
+          "@id": "http://x/row/2",
+          "Reference": {
+            "@value": "A1"
+          },
+          "next": "_:b1"
+        }
+      ]
+    },
+    {
+      "@id": "http://y/other",
+      "@graph": [
+        {
+          "@id": "http://y/s",
+          "http://y/p": {
+            "@value": "0.5",
+            "@type": "xsd:decimal"
+          }
+        }
+      ]
+    }
+  ]
+}
+`, b.String())
+}
+
+func TestWriteJSONLDContextIRI(t *testing.T) {
+    assert := assert.New(t)
+
+    d := NewDataset()
+    d.Default.Add(IRI("http://x/row/1"), IRI("http://z/cause"), NewLiteral("Pump fails"))
+    c := &Context{
+        IRI:   "hazop.context.jsonld",
+        Terms: []ContextTerm{{Name: "Cause", Id: "http://z/cause"}},
+    }
+
+    var b bytes.Buffer
+    assert.Empty(WriteJSONLD(&b, d, c))
+    assert.Equal(`{
+  "@context": "hazop.context.jsonld",
+  "@graph": [
+    {
+      "@id": "http://x/row/1",
+      "Cause": "Pump fails"
+    }
+  ]
+}
+`, b.String())
+
+    b.Reset()
+    assert.Empty(WriteJSONLDContext(&b, c))
+    assert.Contains(b.String(), `"Cause": {`)
+}
+
+func TestWriteJSONLDContext(t *testing.T) {
+    assert := assert.New(t)
+
+    c := &Context{
+        Prefixes: map[string]IRI{"ex": "http://x/"},
+        Terms:    []ContextTerm{{Name: "Cause", Id: "http://z/cause"}},
+    }
+
+    var b bytes.Buffer
+    assert.Empty(WriteJSONLDContext(&b, c))
+    assert.Equal(`{
+  "@context": {
+    "ex": "http://x/",
+    "Cause": {
+      "@id": "http://z/cause"
+    }
+  }
+}
+`, b.String())
+}
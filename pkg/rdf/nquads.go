@@ -0,0 +1,29 @@
+package rdf
+
+import (
+    "bufio"
+    "io"
+)
+
+// WriteNQuads writes the dataset d as N-Quads, the default graph first and
+// the named graphs in dataset order.
+func WriteNQuads(w io.Writer, d *Dataset) error {
+    bw := bufio.NewWriter(w)
+    writeQuads(bw, d.Default.Triples, "")
+    for _, name := range d.Names {
+        writeQuads(bw, d.graphs[name].Triples, " "+name.NTriples())
+    }
+    return bw.Flush()
+}
+
+func writeQuads(bw *bufio.Writer, triples []Triple, graph string) {
+    for _, t := range triples {
+        bw.WriteString(t.Subject.NTriples())
+        bw.WriteString(" ")
+        bw.WriteString(t.Predicate.NTriples())
+        bw.WriteString(" ")
+        bw.WriteString(t.Object.NTriples())
+        bw.WriteString(graph)
+        bw.WriteString(" .\n")
+    }
+}
@@ -0,0 +1,22 @@
+package rdf
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestWriteNQuads(t *testing.T) {
+    assert := assert.New(t)
+
+    d := NewDataset()
+    d.Default.Add(IRI("http://x/g1"), RDFType, IRI("http://x/Sheet"))
+    d.Graph(IRI("http://x/g1")).Add(IRI("http://x/s"), "http://x/p", NewLiteral("o"))
+
+    var b bytes.Buffer
+    assert.Empty(WriteNQuads(&b, d))
+    assert.Equal(`<http://x/g1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://x/Sheet> .
+<http://x/s> <http://x/p> "o" <http://x/g1> .
+`, b.String())
+}
@@ -0,0 +1,14 @@
+package rdf
+
+import (
+    "bufio"
+    "io"
+)
+
+// WriteNTriples writes the graph g as N-Triples, one triple per line in
+// insertion order.
+func WriteNTriples(w io.Writer, g *Graph) error {
+    bw := bufio.NewWriter(w)
+    writeQuads(bw, g.Triples, "")
+    return bw.Flush()
+}
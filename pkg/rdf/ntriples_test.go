@@ -0,0 +1,23 @@
+package rdf
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestWriteNTriples(t *testing.T) {
+    assert := assert.New(t)
+
+    g := NewGraph()
+    g.Bind("ex", "http://x/")
+    g.Add(IRI("http://x/s"), "http://x/p", NewLiteral("line 1\nline \"2\""))
+    g.Add(BlankNode("b1"), RDFType, IRI("http://x/C"))
+
+    var b bytes.Buffer
+    assert.Empty(WriteNTriples(&b, g))
+    assert.Equal(`<http://x/s> <http://x/p> "line 1\nline \"2\"" .
+_:b1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://x/C> .
+`, b.String())
+}
@@ -0,0 +1,243 @@
+package rdf
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+const (
+    RDF  = IRI("http://www.w3.org/1999/02/22-rdf-syntax-ns#")
+    RDFS = IRI("http://www.w3.org/2000/01/rdf-schema#")
+    XSD  = IRI("http://www.w3.org/2001/XMLSchema#")
+)
+
+var (
+    RDFType     = RDF + "type"
+    RDFLangStr  = RDF + "langString"
+    XSDString   = XSD + "string"
+    XSDBoolean  = XSD + "boolean"
+    XSDInteger  = XSD + "integer"
+    XSDDecimal  = XSD + "decimal"
+    XSDDouble   = XSD + "double"
+    XSDDate     = XSD + "date"
+    XSDDateTime = XSD + "dateTime"
+)
+
+var (
+    ErrInvalidLangTag = errors.New("Error invalid language tag")
+    ErrInvalidPrefix  = errors.New("Error invalid prefix")
+)
+
+var (
+    langTag   = regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)
+    prefixTag = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_.-]*[A-Za-z0-9_-]|[A-Za-z]?)$`)
+)
+
+// Term is an RDF term: IRI, BlankNode or Literal.
+type Term interface {
+    // NTriples returns the term in N-Triples syntax.
+    NTriples() string
+}
+
+type IRI string
+
+type BlankNode string
+
+type Literal struct {
+    Value    string
+    Datatype IRI
+    Lang     string
+}
+
+func (t IRI) NTriples() string {
+    return "<" + escapeIRI(string(t)) + ">"
+}
+
+func (t BlankNode) NTriples() string {
+    return "_:" + blankLabel(string(t))
+}
+
+func (t Literal) NTriples() string {
+    s := `"` + EscapeLiteral(t.Value) + `"`
+    switch {
+    case t.Lang != "":
+        return s + "@" + t.Lang
+    case t.Datatype != "" && t.Datatype != XSDString:
+        return s + "^^" + t.Datatype.NTriples()
+    default:
+        return s
+    }
+}
+
+// NewLiteral returns a plain string literal.
+func NewLiteral(value string) Literal {
+    return Literal{Value: value}
+}
+
+// NewTypedLiteral returns a literal of the given datatype.
+func NewTypedLiteral(value string, datatype IRI) Literal {
+    return Literal{Value: value, Datatype: datatype}
+}
+
+// NewLangLiteral returns a language tagged string literal.
+func NewLangLiteral(value, lang string) (Literal, error) {
+    if !langTag.MatchString(lang) {
+        return Literal{}, fmt.Errorf("%v `%s`", ErrInvalidLangTag, lang)
+    }
+    return Literal{Value: value, Datatype: RDFLangStr, Lang: strings.ToLower(lang)}, nil
+}
+
+// NewValueLiteral returns a literal typed by the Go type of value: integers
+// as xsd:integer, floats as xsd:decimal (xsd:double for NaN and infinity),
+// booleans as xsd:boolean and everything else as plain string.
+func NewValueLiteral(value interface{}) Literal {
+    switch v := value.(type) {
+    case int:
+        return NewTypedLiteral(strconv.Itoa(v), XSDInteger)
+    case int64:
+        return NewTypedLiteral(strconv.FormatInt(v, 10), XSDInteger)
+    case float32:
+        return newFloatLiteral(float64(v))
+    case float64:
+        return newFloatLiteral(v)
+    case bool:
+        return NewTypedLiteral(strconv.FormatBool(v), XSDBoolean)
+    case string:
+        return NewLiteral(v)
+    default:
+        return NewLiteral(fmt.Sprint(v))
+    }
+}
+
+func newFloatLiteral(v float64) Literal {
+    switch {
+    case math.IsNaN(v):
+        return NewTypedLiteral("NaN", XSDDouble)
+    case math.IsInf(v, 1):
+        return NewTypedLiteral("INF", XSDDouble)
+    case math.IsInf(v, -1):
+        return NewTypedLiteral("-INF", XSDDouble)
+    default:
+        return NewTypedLiteral(strconv.FormatFloat(v, 'f', -1, 64), XSDDecimal)
+    }
+}
+
+type Triple struct {
+    Subject   Term
+    Predicate IRI
+    Object    Term
+}
+
+// Graph is an ordered set of triples. Triples keep their insertion order,
+// duplicates are dropped, so equal input always gives equal output.
+type Graph struct {
+    Prefixes map[string]IRI
+    Triples  []Triple
+    seen     map[Triple]bool
+}
+
+func NewGraph() *Graph {
+    return &Graph{
+        Prefixes: make(map[string]IRI),
+        seen:     make(map[Triple]bool),
+    }
+}
+
+// Bind binds the prefix to the namespace ns for compact serializations.
+func (g *Graph) Bind(prefix string, ns IRI) error {
+    if !prefixTag.MatchString(prefix) {
+        return fmt.Errorf("%v `%s`", ErrInvalidPrefix, prefix)
+    }
+    g.Prefixes[prefix] = ns
+    return nil
+}
+
+// Add adds the triple (s, p, o) unless the graph already contains it.
+func (g *Graph) Add(s Term, p IRI, o Term) {
+    t := Triple{Subject: s, Predicate: p, Object: o}
+    if g.seen[t] {
+        return
+    }
+    g.seen[t] = true
+    g.Triples = append(g.Triples, t)
+}
+
+func (g *Graph) Len() int {
+    return len(g.Triples)
+}
+
+// EscapeLiteral escapes s for a double quoted Turtle or N-Triples string.
+// Invalid UTF-8 sequences are replaced by the replacement character.
+func EscapeLiteral(s string) string {
+    var b strings.Builder
+    for len(s) > 0 {
+        r, size := utf8.DecodeRuneInString(s)
+        s = s[size:]
+
+        switch r {
+        case '"':
+            b.WriteString(`\"`)
+        case '\\':
+            b.WriteString(`\\`)
+        case '\n':
+            b.WriteString(`\n`)
+        case '\r':
+            b.WriteString(`\r`)
+        case '\t':
+            b.WriteString(`\t`)
+        case '\b':
+            b.WriteString(`\b`)
+        case '\f':
+            b.WriteString(`\f`)
+        default:
+            if r < 0x20 || r == 0x7f {
+                fmt.Fprintf(&b, `\u%04X`, r)
+            } else {
+                b.WriteRune(r)
+            }
+        }
+    }
+
+    return b.String()
+}
+
+// escapeIRI escapes the characters not allowed in an IRIREF.
+func escapeIRI(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        if r <= 0x20 || strings.ContainsRune("<>\"{}|^`\\", r) {
+            fmt.Fprintf(&b, `\u%04X`, r)
+        } else {
+            b.WriteRune(r)
+        }
+    }
+    return b.String()
+}
+
+// blankLabel replaces the characters not allowed in a blank node label.
+func blankLabel(s string) string {
+    var b strings.Builder
+    for i, r := range s {
+        switch {
+        case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+            b.WriteRune(r)
+        case i > 0 && (r == '-' || r == '.'):
+            b.WriteRune(r)
+        default:
+            b.WriteRune('_')
+        }
+    }
+    if b.Len() == 0 {
+        return "b"
+    }
+    label := b.String()
+    if strings.HasSuffix(label, ".") {
+        label = label[:len(label)-1] + "_"
+    }
+    return label
+}
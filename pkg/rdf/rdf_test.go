@@ -0,0 +1,74 @@
+package rdf
+
+import (
+    "math"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLiteral(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal(`txt`, EscapeLiteral("txt"))
+    assert.Equal(`say \"no\"`, EscapeLiteral(`say "no"`))
+    assert.Equal(`C:\\dir`, EscapeLiteral(`C:\dir`))
+    assert.Equal(`1.\n2.\r\n3.\t`, EscapeLiteral("1.\n2.\r\n3.\t"))
+    assert.Equal(`\u0001`, EscapeLiteral("\x01"))
+    assert.Equal("\uFFFD", EscapeLiteral("\xff"))
+    assert.Equal("Dead man’s handle", EscapeLiteral("Dead man’s handle"))
+}
+
+func TestNTriplesTerms(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal(`<http://x/a%20b>`, IRI("http://x/a%20b").NTriples())
+    assert.Equal(`<http://x/a\u0020b\u003E>`, IRI("http://x/a b>").NTriples())
+    assert.Equal(`_:row1`, BlankNode("row1").NTriples())
+    assert.Equal(`_:row_1_`, BlankNode("row 1.").NTriples())
+    assert.Equal(`"txt"`, NewLiteral("txt").NTriples())
+    assert.Equal(`"txt"`, NewTypedLiteral("txt", XSDString).NTriples())
+    assert.Equal(`"5"^^<http://www.w3.org/2001/XMLSchema#integer>`, NewValueLiteral(5).NTriples())
+}
+
+func TestNewLangLiteral(t *testing.T) {
+    assert := assert.New(t)
+
+    lit, err := NewLangLiteral("txt", "en-GB")
+    assert.Empty(err)
+    assert.Equal(`"txt"@en-gb`, lit.NTriples())
+
+    _, err = NewLangLiteral("txt", "en GB")
+    assert.Error(err)
+
+    _, err = NewLangLiteral("txt", "")
+    assert.Error(err)
+}
+
+func TestNewValueLiteral(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal(NewTypedLiteral("12", XSDInteger), NewValueLiteral(12))
+    assert.Equal(NewTypedLiteral("0.25", XSDDecimal), NewValueLiteral(0.25))
+    assert.Equal(NewTypedLiteral("2", XSDDecimal), NewValueLiteral(float32(2)))
+    assert.Equal(NewTypedLiteral("0.000001", XSDDecimal), NewValueLiteral(1e-6))
+    assert.Equal(NewTypedLiteral("INF", XSDDouble), NewValueLiteral(math.Inf(1)))
+    assert.Equal(NewTypedLiteral("NaN", XSDDouble), NewValueLiteral(math.NaN()))
+    assert.Equal(NewTypedLiteral("true", XSDBoolean), NewValueLiteral(true))
+    assert.Equal(NewLiteral("txt"), NewValueLiteral("txt"))
+}
+
+func TestGraph(t *testing.T) {
+    assert := assert.New(t)
+
+    g := NewGraph()
+    assert.Empty(g.Bind("ex", "http://x/"))
+    assert.Empty(g.Bind("", "http://y/"))
+    assert.Error(g.Bind("1ex", "http://x/"))
+    assert.Error(g.Bind("ex:", "http://x/"))
+
+    g.Add(IRI("http://x/s"), "http://x/p", NewLiteral("o"))
+    g.Add(IRI("http://x/s"), "http://x/p", NewLiteral("o"))
+    g.Add(IRI("http://x/s"), "http://x/p", NewTypedLiteral("o", XSDString))
+    assert.Equal(2, g.Len())
+}
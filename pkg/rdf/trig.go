@@ -0,0 +1,22 @@
+package rdf
+
+import (
+    "bufio"
+    "io"
+)
+
+// WriteTriG writes the dataset d as TriG. The default graph is written
+// first, followed by one block per named graph in dataset order.
+func WriteTriG(w io.Writer, d *Dataset) error {
+    bw := bufio.NewWriter(w)
+    writePrefixes(bw, d.Prefixes)
+    writeTriples(bw, d.Default.Triples, d.Prefixes, "")
+
+    for _, name := range d.Names {
+        bw.WriteString("\n" + turtleTerm(name, d.Prefixes) + " {")
+        writeTriples(bw, d.graphs[name].Triples, d.Prefixes, "\t")
+        bw.WriteString("}\n")
+    }
+
+    return bw.Flush()
+}
@@ -0,0 +1,37 @@
+package rdf
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestWriteTriG(t *testing.T) {
+    assert := assert.New(t)
+
+    d := NewDataset()
+    d.Bind("ex", "http://x/")
+
+    d.Default.Add(IRI("http://x/g1"), RDFType, IRI("http://x/Sheet"))
+    g := d.Graph(IRI("http://x/g1"))
+    g.Add(IRI("http://x/s"), "http://x/p", NewLiteral("o"))
+    g.Add(IRI("http://x/s"), "http://x/q", NewValueLiteral(1))
+    d.Graph(IRI("http://y/g 2")).Add(BlankNode("b"), "http://x/p", NewLiteral("o"))
+
+    var b bytes.Buffer
+    assert.Empty(WriteTriG(&b, d))
+    assert.Equal(`@prefix ex: <http://x/> .
+
+ex:g1 a ex:Sheet .
+
+ex:g1 {
+	ex:s ex:p "o" ;
+		ex:q "1"^^<http://www.w3.org/2001/XMLSchema#integer> .
+}
+
+<http://y/g\u00202> {
+	_:b ex:p "o" .
+}
+`, b.String())
+}
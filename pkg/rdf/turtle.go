@@ -0,0 +1,120 @@
+package rdf
+
+import (
+    "bufio"
+    "io"
+    "regexp"
+    "sort"
+    "strings"
+)
+
+var localName = regexp.MustCompile(`^([A-Za-z0-9_]([A-Za-z0-9_.-]*[A-Za-z0-9_-])?)?$`)
+
+// WriteTurtle writes the graph g as Turtle. Prefixes are sorted by name,
+// subjects and their predicates keep the order of their first triple and
+// IRIs are compacted with the longest matching prefix.
+func WriteTurtle(w io.Writer, g *Graph) error {
+    bw := bufio.NewWriter(w)
+    writePrefixes(bw, g.Prefixes)
+    writeTriples(bw, g.Triples, g.Prefixes, "")
+    return bw.Flush()
+}
+
+func writePrefixes(bw *bufio.Writer, prefixes map[string]IRI) {
+    names := make([]string, 0, len(prefixes))
+    for p := range prefixes {
+        names = append(names, p)
+    }
+    sort.Strings(names)
+
+    for _, p := range names {
+        bw.WriteString("@prefix " + p + ": " + prefixes[p].NTriples() + " .\n")
+    }
+}
+
+// writeTriples writes the triples grouped by subject and predicate, every
+// line is prefixed by indent.
+func writeTriples(bw *bufio.Writer, triples []Triple, prefixes map[string]IRI, indent string) {
+    var (
+        subjects   []Term
+        predicates = make(map[Term][]IRI)
+        objects    = make(map[Term]map[IRI][]Term)
+    )
+    for _, t := range triples {
+        if _, ok := objects[t.Subject]; !ok {
+            subjects = append(subjects, t.Subject)
+            objects[t.Subject] = make(map[IRI][]Term)
+        }
+        if _, ok := objects[t.Subject][t.Predicate]; !ok {
+            predicates[t.Subject] = append(predicates[t.Subject], t.Predicate)
+        }
+        objects[t.Subject][t.Predicate] = append(objects[t.Subject][t.Predicate], t.Object)
+    }
+
+    for _, s := range subjects {
+        bw.WriteString("\n" + indent + turtleTerm(s, prefixes))
+        for i, p := range predicates[s] {
+            if i > 0 {
+                bw.WriteString(" ;\n" + indent + "\t")
+            } else {
+                bw.WriteString(" ")
+            }
+
+            if p == RDFType {
+                bw.WriteString("a")
+            } else {
+                bw.WriteString(turtleTerm(p, prefixes))
+            }
+
+            for j, o := range objects[s][p] {
+                if j > 0 {
+                    bw.WriteString(",")
+                }
+                bw.WriteString(" " + turtleTerm(o, prefixes))
+            }
+        }
+        bw.WriteString(" .\n")
+    }
+}
+
+func turtleTerm(t Term, prefixes map[string]IRI) string {
+    switch v := t.(type) {
+    case IRI:
+        return compact(v, prefixes)
+    case Literal:
+        s := `"` + EscapeLiteral(v.Value) + `"`
+        switch {
+        case v.Lang != "":
+            return s + "@" + v.Lang
+        case v.Datatype != "" && v.Datatype != XSDString:
+            return s + "^^" + compact(v.Datatype, prefixes)
+        default:
+            return s
+        }
+    default:
+        return t.NTriples()
+    }
+}
+
+// compact returns the prefixed name of iri for the longest matching
+// namespace or the full IRI if no prefix applies.
+func compact(iri IRI, prefixes map[string]IRI) string {
+    var best, local string
+    var found bool
+    for p, ns := range prefixes {
+        if !strings.HasPrefix(string(iri), string(ns)) {
+            continue
+        }
+        l := strings.TrimPrefix(string(iri), string(ns))
+        if !localName.MatchString(l) {
+            continue
+        }
+        if !found || len(ns) > len(prefixes[best]) || (len(ns) == len(prefixes[best]) && p < best) {
+            best, local, found = p, l, true
+        }
+    }
+    if !found {
+        return iri.NTriples()
+    }
+    return best + ":" + local
+}
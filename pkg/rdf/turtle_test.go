@@ -0,0 +1,50 @@
+package rdf
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestWriteTurtle(t *testing.T) {
+    assert := assert.New(t)
+
+    g := NewGraph()
+    g.Bind("xsd", XSD)
+    g.Bind("ex", "http://x/")
+    g.Bind("exp", "http://x/p#")
+
+    s1 := IRI("http://x/node/1")
+    s2 := IRI("http://y/node 2")
+    g.Add(s1, RDFType, IRI("http://x/Row"))
+    g.Add(s1, "http://x/p#cause", NewLiteral("a \"b\""))
+    g.Add(s2, "http://x/p#reference", NewValueLiteral(2))
+    g.Add(s1, "http://x/p#cause", NewLiteral("c"))
+    lit, _ := NewLangLiteral("d", "en")
+    g.Add(s1, "http://x/p#action", lit)
+
+    var b bytes.Buffer
+    assert.Empty(WriteTurtle(&b, g))
+    assert.Equal(`@prefix ex: <http://x/> .
+@prefix exp: <http://x/p#> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+
+<http://x/node/1> a ex:Row ;
+	exp:cause "a \"b\"", "c" ;
+	exp:action "d"@en .
+
+<http://y/node\u00202> exp:reference "2"^^xsd:integer .
+`, b.String())
+}
+
+func TestCompact(t *testing.T) {
+    assert := assert.New(t)
+
+    prefixes := map[string]IRI{"ex": "http://x/", "exn": "http://x/n#"}
+    assert.Equal("exn:a", compact("http://x/n#a", prefixes))
+    assert.Equal("ex:a", compact("http://x/a", prefixes))
+    assert.Equal("ex:", compact("http://x/", prefixes))
+    assert.Equal("<http://x/a/b>", compact("http://x/a/b", prefixes))
+    assert.Equal("<http://z/a>", compact("http://z/a", prefixes))
+}